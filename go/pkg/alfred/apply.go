@@ -0,0 +1,62 @@
+package alfred
+
+import (
+	"reflect"
+	"time"
+)
+
+// Apply filters, sorts and paginates items in memory, with the same semantics as
+// AddToPSQLQuery : items are kept by the filters, sorted by the sort keys, and the page
+// is the Limit items after the first Offset ones (all of them if Limit <= 0).
+// The total is the number of items kept by the filters, like the count(*) OVER() column.
+// With a cursor, items are kept only after the cursor position and the total is the
+// number of remaining items.
+// Relative time values are resolved against opt.Now.
+// The items slice is not modified.
+func Apply[T any](items []T, opt Option) (page []T, total int, err error) {
+	now := opt.now()
+	kept, err := filterItems(items, resolveTimes(reflect.TypeFor[T](), opt.filters(), now), now)
+	if err != nil {
+		return nil, 0, err
+	}
+	total = len(kept)
+
+	opt.Sort(kept)
+
+	offset := min(max(opt.Offset, 0), total)
+	end := total
+	if opt.Limit > 0 {
+		end = min(offset+opt.Limit, total)
+	}
+	return kept[offset:end], total, nil
+}
+
+// filterItems return the items kept by the filters, in a new slice. Relative times are resolved
+// against now.
+func filterItems[T any](items []T, filters Filters, now time.Time) ([]T, error) {
+	keep := filters.Keep
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// filters of a struct type are compiled, others are applied as is
+	if len(filters) > 0 && t.Kind() == reflect.Struct {
+		c, err := compileFilters(t, filters, now)
+		if err != nil {
+			return nil, err
+		}
+		keep = c.Keep
+	}
+
+	kept := make([]T, 0, len(items))
+	for _, item := range items {
+		keep, err := keep(item)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
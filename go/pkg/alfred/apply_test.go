@@ -0,0 +1,64 @@
+package alfred
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	type hero struct {
+		Name string `filter:"name"`
+		Team string `filter:"team"`
+		Age  int    `filter:"age"`
+	}
+	bruce := hero{Name: "Bruce", Team: "jla", Age: 39}
+	clark := hero{Name: "Clark", Team: "jla", Age: 35}
+	diana := hero{Name: "Diana", Team: "jla", Age: 5000}
+	tony := hero{Name: "Tony", Team: "avengers", Age: 48}
+	items := []hero{tony, diana, bruce, clark}
+
+	tests := map[string]struct {
+		opt   Option
+		want  []hero
+		total int
+	}{
+		"all":           {opt: Option{}, want: []hero{tony, diana, bruce, clark}, total: 4},
+		"filter":        {opt: Option{Filters: Filters{EQ{"team", "jla"}}}, want: []hero{diana, bruce, clark}, total: 3},
+		"sort":          {opt: Option{SortBy: "name"}, want: []hero{bruce, clark, diana, tony}, total: 4},
+		"limit":         {opt: Option{SortBy: "name", Limit: 2}, want: []hero{bruce, clark}, total: 4},
+		"offset":        {opt: Option{SortBy: "name", Offset: 3}, want: []hero{tony}, total: 4},
+		"limit-offset":  {opt: Option{SortBy: "-age", Limit: 2, Offset: 1, Filters: Filters{Not{EQ{"name", "Diana"}}}}, want: []hero{bruce, clark}, total: 3},
+		"offset-over":   {opt: Option{Offset: 10}, want: []hero{}, total: 4},
+		"limit-over":    {opt: Option{Offset: 2, Limit: 10}, want: []hero{bruce, clark}, total: 4},
+		"negative":      {opt: Option{Offset: -1, Limit: -1}, want: []hero{tony, diana, bruce, clark}, total: 4},
+		"filtered-none": {opt: Option{Filters: Filters{EQ{"team", "x-men"}}}, want: []hero{}, total: 0},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, total, err := Apply(items, tt.opt)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, page)
+			assert.Equal(t, tt.total, total)
+			assert.Equal(t, []hero{tony, diana, bruce, clark}, items)
+		})
+	}
+}
+
+func TestApplyError(t *testing.T) {
+	_, _, err := Apply([]int{1, 2}, Option{Filters: Filters{EQ{"name", "bruce"}}})
+	assert.Error(t, err)
+}
+
+func TestApplyCompileError(t *testing.T) {
+	type hero struct {
+		Name string `filter:"name"`
+		Age  int    `filter:"age"`
+	}
+	// the invalid value is reported even if no item reaches the filter
+	opt := Option{Filters: Filters{Or{EQ{"name", "Bruce"}, GT{"age", "old"}}}}
+	_, _, err := Apply([]hero{{Name: "Bruce", Age: 39}}, opt)
+	assert.Error(t, err)
+	_, _, err = Apply([]hero{}, opt)
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package alfred
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+const (
+	nullsFirst = "first"
+	nullsLast  = "last"
+)
+
+// nulls return the normalized null ordering of the option : nullsFirst, nullsLast, or empty
+// for the default ordering.
+func (flt Option) nulls() string {
+	switch s := strings.ToLower(strings.TrimSpace(flt.Nulls)); s {
+	case nullsFirst, nullsLast:
+		return s
+	default:
+		return ""
+	}
+}
+
+// keyNulls return the position of the null values of a sort key, with the normalized null
+// ordering nulls : nulls if not empty, otherwise first for an ascending key and last for a
+// descending key, null values being lower than any other value.
+func keyNulls(nulls string, desc bool) string {
+	switch {
+	case nulls != "":
+		return nulls
+	case desc:
+		return nullsLast
+	default:
+		return nullsFirst
+	}
+}
+
+// newCollator return a collator of the collation, a BCP 47 language tag ('fr', 'de-u-co-phonebk').
+func newCollator(collation string) (*collate.Collator, error) {
+	tag, err := language.Parse(collation)
+	if err != nil {
+		return nil, err
+	}
+	return collate.New(tag), nil
+}
+
+// CollationFunc return the function comparing strings in the order of the collation, a BCP 47
+// language tag ('fr'). It returns -1, 0 or +1 depending on whether a is less than, equal to, or
+// greater than b. It can be registered as the collation of a SQLite connection, see SQLite.Collate.
+func CollationFunc(collation string) (func(a, b string) int, error) {
+	if _, err := newCollator(collation); err != nil {
+		return nil, err
+	}
+	// a collator is not safe for concurrent use
+	pool := &sync.Pool{New: func() any {
+		c, _ := newCollator(collation)
+		return c
+	}}
+	return func(a, b string) int {
+		c := pool.Get().(*collate.Collator)
+		defer pool.Put(c)
+		return c.CompareString(a, b)
+	}, nil
+}
@@ -0,0 +1,182 @@
+package alfred
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type collateHero struct {
+	ID   int     `filter:"id"`
+	Name string  `filter:"name"`
+	Nick *string `filter:"nick"`
+}
+
+func collateHeroes() []collateHero {
+	return []collateHero{
+		{1, "Zoé", ptr("z")},
+		{2, "Émile", nil},
+		{3, "eve", ptr("e")},
+		{4, "Édith", ptr("a")},
+		{5, "Eve", nil},
+	}
+}
+
+func collateIDs(heroes []collateHero) []int {
+	ids := make([]int, len(heroes))
+	for i, h := range heroes {
+		ids[i] = h.ID
+	}
+	return ids
+}
+
+func TestCollationFunc(t *testing.T) {
+	compare, err := CollationFunc("fr")
+	require.NoError(t, err)
+	assert.Equal(t, -1, compare("Émile", "Eve"))
+	assert.Equal(t, 1, compare("Zoé", "Eve"))
+	assert.Equal(t, 0, compare("Eve", "Eve"))
+
+	_, err = CollationFunc("not a tag")
+	assert.Error(t, err)
+}
+
+func TestSortCollation(t *testing.T) {
+	tests := map[string]struct {
+		opt  Option
+		want []int
+	}{
+		"default":   {opt: Option{SortBy: "name"}, want: []int{5, 3, 1, 4, 2}},
+		"collation": {opt: Option{SortBy: "name", Collation: "fr"}, want: []int{4, 2, 3, 5, 1}},
+		"desc":      {opt: Option{SortBy: "-name", Collation: "fr"}, want: []int{1, 5, 3, 2, 4}},
+		"invalid":   {opt: Option{SortBy: "name", Collation: "not a tag"}, want: []int{5, 3, 1, 4, 2}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			heroes := collateHeroes()
+			tt.opt.Sort(heroes)
+			assert.Equal(t, tt.want, collateIDs(heroes))
+		})
+	}
+}
+
+func TestSortNulls(t *testing.T) {
+	tests := map[string]struct {
+		opt  Option
+		want []int
+	}{
+		"default":    {opt: Option{SortBy: "nick,id"}, want: []int{2, 5, 4, 3, 1}},
+		"default-d":  {opt: Option{SortBy: "-nick,id"}, want: []int{1, 3, 4, 2, 5}},
+		"first":      {opt: Option{SortBy: "nick,id", Nulls: "first"}, want: []int{2, 5, 4, 3, 1}},
+		"first-desc": {opt: Option{SortBy: "-nick,id", Nulls: "FIRST"}, want: []int{2, 5, 1, 3, 4}},
+		"last":       {opt: Option{SortBy: "nick,id", Nulls: "last"}, want: []int{4, 3, 1, 2, 5}},
+		"last-desc":  {opt: Option{SortBy: "-nick,id", Nulls: "last"}, want: []int{1, 3, 4, 2, 5}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			heroes := collateHeroes()
+			tt.opt.Sort(heroes)
+			assert.Equal(t, tt.want, collateIDs(heroes))
+		})
+	}
+}
+
+func TestOrderBySQL(t *testing.T) {
+	opt := Option{SortBy: "name,-id", Collation: "de", Nulls: "last"}
+	schema := NewSchema(collateHero{})
+	tests := map[string]struct {
+		builder *SQLBuilder
+		want    string
+	}{
+		"postgres": {
+			builder: &SQLBuilder{Dialect: Postgres{}, Schema: schema},
+			want:    `ORDER BY "name" COLLATE "de-x-icu" ASC NULLS LAST, "id" DESC NULLS LAST`,
+		},
+		"postgres-no-schema": {
+			builder: &SQLBuilder{Dialect: Postgres{}},
+			want:    `ORDER BY "name" COLLATE "de-x-icu" ASC NULLS LAST, "id" COLLATE "de-x-icu" DESC NULLS LAST`,
+		},
+		"sqlite": {
+			builder: &SQLBuilder{Dialect: SQLite{}, Schema: schema},
+			want:    `ORDER BY "name" COLLATE "de" ASC NULLS LAST, "id" DESC NULLS LAST`,
+		},
+		"mysql": {
+			builder: &SQLBuilder{Dialect: MySQL{}, Schema: schema},
+			want:    "ORDER BY `name` COLLATE utf8mb4_de_pb_0900_ai_ci IS NULL ASC, `name` COLLATE utf8mb4_de_pb_0900_ai_ci ASC, `id` IS NULL ASC, `id` DESC",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Contains(t, tt.builder.Query("SELECT * FROM heroes", opt), tt.want+" LIMIT")
+		})
+	}
+
+	assert.Equal(t, "`name` COLLATE utf8mb4_0900_ai_ci", MySQL{}.Collate("`name`", "fr"))
+	assert.Equal(t, "`name` IS NULL DESC, `name` DESC", MySQL{}.OrderBy("`name`", true, "first"))
+	assert.Equal(t, `"name" ASC NULLS FIRST`, Postgres{}.OrderBy(`"name"`, false, "first"))
+	assert.Equal(t, `"name" DESC`, SQLite{}.OrderBy(`"name"`, true, ""))
+}
+
+func TestSQLiteCollation(t *testing.T) {
+	compare, err := CollationFunc("fr")
+	require.NoError(t, err)
+	sql.Register("sqlite3_collate", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterCollation("fr", compare)
+		},
+	})
+	db, err := sql.Open("sqlite3_collate", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`CREATE TABLE heroes (id INTEGER PRIMARY KEY, name TEXT, nick TEXT)`)
+	require.NoError(t, err)
+	for _, h := range collateHeroes() {
+		_, err = db.Exec(`INSERT INTO heroes VALUES (?, ?, ?)`, h.ID, h.Name, h.Nick)
+		require.NoError(t, err)
+	}
+
+	b := &SQLBuilder{Dialect: SQLite{}, Schema: NewSchema(collateHero{})}
+	for _, opt := range []Option{
+		{SortBy: "name", Collation: "fr"},
+		{SortBy: "-name", Collation: "fr"},
+		{SortBy: "nick,id", Nulls: "last"},
+		{SortBy: "-nick,id", Nulls: "first"},
+	} {
+		query := b.Query("SELECT * FROM heroes", opt)
+		rows, err := db.Query(query, b.Args()...)
+		require.NoError(t, err)
+		var ids []int
+		for rows.Next() {
+			var h collateHero
+			var total int
+			require.NoError(t, rows.Scan(&h.ID, &h.Name, &h.Nick, &total))
+			ids = append(ids, h.ID)
+		}
+		require.NoError(t, rows.Close())
+
+		heroes := collateHeroes()
+		opt.Sort(heroes)
+		assert.Equal(t, collateIDs(heroes), ids, query)
+		b = &SQLBuilder{Dialect: SQLite{}, Schema: b.Schema}
+	}
+}
+
+func TestValidateCollation(t *testing.T) {
+	opt := Option{SortBy: "name", Collation: "not a tag", Nulls: "middle"}
+	assert.Equal(t, ErrValidation{
+		{Param: "collation", Value: "not a tag", Reason: "invalid collation, must be a BCP 47 language tag"},
+		{Param: "nulls", Value: "middle", Reason: "invalid nulls ordering, must be first or last"},
+	}, opt.Validate(NewSchema(collateHero{})))
+	assert.NoError(t, Option{SortBy: "name", Collation: "fr-CA", Nulls: "Last"}.Validate(NewSchema(collateHero{})))
+}
+
+func TestURLValuesCollation(t *testing.T) {
+	opt := Option{SortBy: "name", Collation: "fr", Nulls: "last"}
+	values, err := opt.URLValues()
+	require.NoError(t, err)
+	assert.Equal(t, opt, ParseURLValues(values))
+}
@@ -0,0 +1,364 @@
+package alfred
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// matcher is a filter compiled for a struct type, applied on a struct value.
+type matcher func(rv reflect.Value) (bool, error)
+
+// CompiledFilters are filters compiled for a struct type. Fields are resolved and values
+// are parsed once, instead of on each call of Keep.
+type CompiledFilters struct {
+	typ     reflect.Type
+	filters Filters
+	match   matcher
+}
+
+// Compile return the filters compiled for the struct type of v, or of the struct pointed by v.
+// Unlike Filters.Keep, invalid values and fields of unsupported types are reported here,
+// whether or not a struct would reach the filter. Relative time values ('now-7d') are resolved
+// once, against the time of the compilation.
+func (fs Filters) Compile(v any) (*CompiledFilters, error) {
+	return compileFilters(reflect.TypeOf(v), fs, time.Now())
+}
+
+func compileFilters(t reflect.Type, fs Filters, now time.Time) (*CompiledFilters, error) {
+	st := t
+	for st != nil && st.Kind() == reflect.Pointer {
+		st = st.Elem()
+	}
+	if st == nil || st.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("filter : not a struct (%v)", t)
+	}
+	match, err := compileFilter(st, And(fs), now)
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledFilters{typ: st, filters: fs, match: match}, nil
+}
+
+// Keep return true if the struct v is kept by the filters, like Filters.Keep.
+// Values of another type than the compiled one are given to Filters.Keep.
+func (c *CompiledFilters) Keep(v any) (bool, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Type() != c.typ {
+		return c.filters.Keep(v)
+	}
+	return c.match(rv)
+}
+
+func compileFilter(t reflect.Type, f Filter, now time.Time) (matcher, error) {
+	switch f := f.(type) {
+	case And:
+		ms, err := compileAll(t, f, now)
+		if err != nil {
+			return nil, err
+		}
+		return func(rv reflect.Value) (bool, error) {
+			for _, m := range ms {
+				if keep, err := m(rv); !keep {
+					return false, err
+				}
+			}
+			return true, nil
+		}, nil
+	case Or:
+		ms, err := compileAll(t, f, now)
+		if err != nil {
+			return nil, err
+		}
+		return func(rv reflect.Value) (bool, error) {
+			for _, m := range ms {
+				keep, err := m(rv)
+				if err != nil {
+					return false, err
+				}
+				if keep {
+					return true, nil
+				}
+			}
+			return false, nil
+		}, nil
+	case Not:
+		not, ok := negate(f.Filter).(Not)
+		if !ok {
+			return compileFilter(t, negate(f.Filter), now)
+		}
+		m, err := compileFilter(t, not.Filter, now)
+		if err != nil {
+			return nil, err
+		}
+		// the null values of the fields are not kept, see Not.Keep
+		var nulls []func(rv reflect.Value) bool
+		for _, field := range nullableFields(not.Filter) {
+			index, sf, found := resolveField(t, field)
+			if !found {
+				continue
+			}
+			scalar := scalarOf(sf.Type).scalar
+			nulls = append(nulls, func(rv reflect.Value) bool {
+				fv, ok := fieldByIndex(rv, index)
+				if ok {
+					_, ok = scalar(fv)
+				}
+				return !ok
+			})
+		}
+		return func(rv reflect.Value) (bool, error) {
+			for _, null := range nulls {
+				if null(rv) {
+					return false, nil
+				}
+			}
+			keep, err := m(rv)
+			return !keep && err == nil, err
+		}, nil
+	case Seek:
+		return compileSeek(t, f, now)
+	}
+
+	param, op, _, ok := filterOp(f)
+	if !ok {
+		// unknown filter, not compiled
+		return func(rv reflect.Value) (bool, error) { return f.Keep(rv.Interface()) }, nil
+	}
+	index, sf, found := resolveField(t, param)
+	if !found {
+		return func(reflect.Value) (bool, error) { return true, nil }, nil
+	}
+	if !parseTag(sf).allows(op) {
+		return nil, errNotAllowed(param, op)
+	}
+	test, err := compileTest(param, op, filterValues(f), sf.Type, now)
+	if err != nil {
+		return nil, err
+	}
+	scalar := scalarOf(sf.Type).scalar
+	field := func(rv reflect.Value) (reflect.Value, bool) {
+		fv, ok := fieldByIndex(rv, index)
+		if !ok {
+			return fv, false
+		}
+		return scalar(fv)
+	}
+	if op == "isnull" {
+		return func(rv reflect.Value) (bool, error) {
+			fv, ok := field(rv)
+			return test(fv, ok), nil
+		}, nil
+	}
+	return func(rv reflect.Value) (bool, error) {
+		fv, ok := field(rv)
+		if !ok {
+			// null value, or nil pointer on the path
+			return false, nil
+		}
+		return test(fv, ok), nil
+	}, nil
+}
+
+func compileAll(t reflect.Type, fs []Filter, now time.Time) ([]matcher, error) {
+	ms := make([]matcher, len(fs))
+	for i, f := range fs {
+		m, err := compileFilter(t, f, now)
+		if err != nil {
+			return nil, err
+		}
+		ms[i] = m
+	}
+	return ms, nil
+}
+
+// compileTest return the test of the operator op with the values on a field of type t, relative
+// times being resolved against now. The test is given the compared value of the field, and false
+// if it is null.
+func compileTest(param, op string, values []string, t reflect.Type, now time.Time) (func(fv reflect.Value, ok bool) bool, error) {
+	kind := kindOf(t)
+	value := strings.Join(values, ",")
+	errType := fmt.Errorf("filter : unsuported field type (%v as %v)", param, t.String())
+
+	switch op {
+	case "like":
+		substr := strings.ToLower(value)
+		return func(fv reflect.Value, _ bool) bool {
+			return strings.Contains(strings.ToLower(fv.String()), substr)
+		}, nil
+	case "startswith":
+		if kind != kindString {
+			return nil, errType
+		}
+		prefix := strings.ToLower(value)
+		return func(fv reflect.Value, _ bool) bool {
+			return strings.HasPrefix(strings.ToLower(fv.String()), prefix)
+		}, nil
+	case "regex":
+		if kind != kindString {
+			return nil, errType
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, ErrParamType{param, err}
+		}
+		return func(fv reflect.Value, _ bool) bool { return re.MatchString(fv.String()) }, nil
+	case "isnull":
+		null, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, ErrParamType{param, err}
+		}
+		return func(fv reflect.Value, ok bool) bool { return (!ok || isNil(fv)) == null }, nil
+	case "contain", "containsAll", "overlaps":
+		if kind == kindString || kind == kindList {
+			test, err := compileList(param, op, t, values, now)
+			if err != nil {
+				return nil, err
+			}
+			return func(fv reflect.Value, _ bool) bool { return test(fv) }, nil
+		}
+		if !slices.Contains(operatorKinds[op], kind) {
+			return nil, errType
+		}
+		op = "eq"
+	}
+
+	if !slices.Contains(operatorKinds[op], kind) {
+		return nil, errType
+	}
+	cmps := make([]func(fv reflect.Value) int, len(values))
+	for i, v := range values {
+		c, err := compileCompare(kind, v, now)
+		if err != nil {
+			return nil, ErrParamType{param, err}
+		}
+		cmps[i] = c
+	}
+
+	switch op {
+	case "eq":
+		return func(fv reflect.Value, _ bool) bool { return cmps[0](fv) == 0 }, nil
+	case "ne":
+		return func(fv reflect.Value, _ bool) bool { return cmps[0](fv) != 0 }, nil
+	case "gt":
+		return func(fv reflect.Value, _ bool) bool { return cmps[0](fv) > 0 }, nil
+	case "gte":
+		return func(fv reflect.Value, _ bool) bool { return cmps[0](fv) >= 0 }, nil
+	case "lt":
+		return func(fv reflect.Value, _ bool) bool { return cmps[0](fv) < 0 }, nil
+	case "lte":
+		return func(fv reflect.Value, _ bool) bool { return cmps[0](fv) <= 0 }, nil
+	case "in", "nin":
+		in := op == "in"
+		return func(fv reflect.Value, _ bool) bool {
+			for _, c := range cmps {
+				if c(fv) == 0 {
+					return in
+				}
+			}
+			return !in
+		}, nil
+	case "between":
+		return func(fv reflect.Value, _ bool) bool { return cmps[0](fv) >= 0 && cmps[1](fv) <= 0 }, nil
+	default:
+		return nil, fmt.Errorf("filter : unknown operator (%v)", op)
+	}
+}
+
+// compileCompare return the function comparing a compared value of kind k with the value s, parsed once,
+// relative times being resolved against now.
+func compileCompare(k valueKind, s string, now time.Time) (func(fv reflect.Value) int, error) {
+	switch k {
+	case kindString:
+		return func(fv reflect.Value) int { return compareStrings(fv.String(), s) }, nil
+	case kindInt:
+		value, err := strconv.ParseInt(s, 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		return func(fv reflect.Value) int { return cmp.Compare(fv.Int(), value) }, nil
+	case kindUint:
+		value, err := strconv.ParseUint(s, 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		return func(fv reflect.Value) int { return cmp.Compare(fv.Uint(), value) }, nil
+	case kindFloat:
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(fv reflect.Value) int { return cmp.Compare(fv.Float(), value) }, nil
+	case kindBool:
+		value, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, err
+		}
+		return func(fv reflect.Value) int { return cmp.Compare(btoi(fv.Bool()), btoi(value)) }, nil
+	case kindTime:
+		value, err := parseTime(s, now)
+		if err != nil {
+			return nil, err
+		}
+		return func(fv reflect.Value) int { return fv.Interface().(time.Time).Compare(value) }, nil
+	case kindDuration:
+		value, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w : supported format time.ParseDuration", err)
+		}
+		return func(fv reflect.Value) int { return cmp.Compare(fv.Int(), int64(value)) }, nil
+	default:
+		return nil, fmt.Errorf("unsuported field type (%v)", k)
+	}
+}
+
+func compileSeek(t reflect.Type, f Seek, now time.Time) (matcher, error) {
+	type seekKey struct {
+		index      []int
+		desc       bool
+		nullsAfter bool
+		scalar     scalarFunc
+		compare    func(fv reflect.Value) int
+	}
+	var keys []seekKey
+	collation := f.collation()
+	for i, key := range f.Keys[:min(len(f.Keys), len(f.Values))] {
+		index, sf, found := resolveField(t, key.Field)
+		if !found {
+			continue
+		}
+		c, err := compileCompare(kindOf(sf.Type), f.Values[i], now)
+		if err != nil {
+			return nil, ErrParamType{key.Field, err}
+		}
+		if collation != nil && kindOf(sf.Type) == kindString {
+			value := f.Values[i]
+			c = func(fv reflect.Value) int { return collation(fv.String(), value) }
+		}
+		keys = append(keys, seekKey{index: index, desc: key.Desc, nullsAfter: f.nullsAfter(key), scalar: scalarOf(sf.Type).scalar, compare: c})
+	}
+	return func(rv reflect.Value) (bool, error) {
+		for _, key := range keys {
+			fv, ok := fieldByIndex(rv, key.index)
+			if ok {
+				fv, ok = key.scalar(fv)
+			}
+			if !ok {
+				// null value, or nil pointer on the path
+				return key.nullsAfter, nil
+			}
+			if c := key.compare(fv); c != 0 {
+				return (c > 0) != key.desc, nil
+			}
+		}
+		return false, nil
+	}, nil
+}
@@ -0,0 +1,166 @@
+package alfred
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type compileHero struct {
+	ID    int       `filter:"id"`
+	Name  string    `filter:"name"`
+	Tags  string    `filter:"tags"`
+	Score float64   `filter:"score"`
+	Alive bool      `filter:"alive"`
+	Birth time.Time `filter:"birth"`
+	Owner *compileHero
+}
+
+func compileHeroes(n int) []compileHero {
+	names := []string{"Bruce", "Clark", "Diana", "Barry", "Arthur", "Hal"}
+	heroes := make([]compileHero, n)
+	for i := range heroes {
+		heroes[i] = compileHero{
+			ID:    i,
+			Name:  names[i%len(names)],
+			Tags:  fmt.Sprintf("tag%d,tag%d", i%3, i%5),
+			Score: float64(i%100) / 10,
+			Alive: i%2 == 0,
+			Birth: time.Date(1900+i%120, time.May, 1, 0, 0, 0, 0, time.UTC),
+		}
+		if i%4 == 0 && i > 0 {
+			heroes[i].Owner = &heroes[i-1]
+		}
+	}
+	return heroes
+}
+
+func TestCompile(t *testing.T) {
+	heroes := compileHeroes(100)
+	filters := map[string]Filter{
+		"like":       Like{"name", "AR"},
+		"eq":         EQ{"alive", "true"},
+		"ne":         NE{"name", "Bruce"},
+		"gt":         GT{"score", "4.5"},
+		"gte":        GTE{"birth", "1950-05-01T00:00:00Z"},
+		"lt":         LT{"id", "30"},
+		"lte":        LTE{"id", "30"},
+		"contain":    Contain{"tags", "tag2"},
+		"in":         In{"name", []string{"Diana", "Hal"}},
+		"nin":        NotIn{"id", []string{"1", "2", "3"}},
+		"between":    Between{"score", "2", "3.5"},
+		"isnull":     IsNull{"Owner", "true"},
+		"isnull-nil": IsNull{"Owner.name", "false"},
+		"startswith": StartsWith{"name", "b"},
+		"regex":      Regex{"name", "^[BC]"},
+		"nested":     EQ{"Owner.name", "Clark"},
+		"unknown":    EQ{"power", "flight"},
+		"logic":      Or{And{GT{"id", "50"}, Not{EQ{"alive", "true"}}}, Like{"name", "hal"}},
+		"seek":       Seek{Keys: []SortKey{{"name", false}, {"id", true}}, Values: []string{"Clark", "40"}},
+	}
+	for name, f := range filters {
+		t.Run(name, func(t *testing.T) {
+			c, err := Filters{f}.Compile(compileHero{})
+			assert.NoError(t, err)
+			for _, hero := range heroes {
+				want, err := f.Keep(hero)
+				assert.NoError(t, err)
+				actual, err := c.Keep(hero)
+				assert.NoError(t, err)
+				assert.Equal(t, want, actual, "%v", hero.ID)
+
+				actual, err = c.Keep(&hero)
+				assert.NoError(t, err)
+				assert.Equal(t, want, actual, "%v", hero.ID)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := map[string]Filter{
+		"value":    GT{"id", "ten"},
+		"type":     GT{"name", "x"},
+		"in-value": In{"score", []string{"1", "x"}},
+		"regex":    Regex{"name", "("},
+		"isnull":   IsNull{"name", "maybe"},
+		"seek":     Seek{Keys: []SortKey{{"birth", false}}, Values: []string{"last week"}},
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Filters{Or{EQ{"id", "1"}, f}}.Compile(&compileHero{})
+			assert.Error(t, err)
+		})
+	}
+	_, err := Filters{}.Compile(42)
+	assert.EqualError(t, err, "filter : not a struct (int)")
+}
+
+func TestCompiledKeepOtherType(t *testing.T) {
+	c, err := Filters{EQ{"name", "Bruce"}}.Compile(compileHero{})
+	assert.NoError(t, err)
+	keep, err := c.Keep(struct{ Name string }{"Bruce"})
+	assert.NoError(t, err)
+	assert.True(t, keep)
+	_, err = c.Keep("Bruce")
+	assert.Error(t, err)
+}
+
+var benchFilters = Filters{
+	Like{"name", "ar"},
+	GTE{"score", "2.5"},
+	LT{"birth", "2000-01-01T00:00:00Z"},
+	Or{EQ{"alive", "true"}, In{"id", []string{"1", "3", "5"}}},
+}
+
+func BenchmarkFiltersKeep(b *testing.B) {
+	heroes := compileHeroes(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, hero := range heroes {
+			if _, err := benchFilters.Keep(hero); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkCompiledFiltersKeep(b *testing.B) {
+	heroes := compileHeroes(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c, err := benchFilters.Compile(compileHero{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, hero := range heroes {
+			if _, err := c.Keep(hero); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkApply(b *testing.B) {
+	heroes := compileHeroes(100000)
+	opt := Option{Filters: benchFilters, SortBy: "name,-score", Limit: 20}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := Apply(heroes, opt); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSort(b *testing.B) {
+	heroes := compileHeroes(100000)
+	opt := Option{SortBy: "name,-score,birth"}
+	arr := make([]compileHero, len(heroes))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(arr, heroes)
+		opt.Sort(arr)
+	}
+}
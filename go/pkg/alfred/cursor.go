@@ -0,0 +1,244 @@
+package alfred
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// encodeCursor return the opaque cursor token of the sort key values.
+func encodeCursor(values []string) string {
+	b, _ := json.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor return the sort key values of the cursor token.
+func decodeCursor(token string) ([]string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var values []string
+	if err := json.Unmarshal(b, &values); err != nil || len(values) == 0 {
+		return nil, errors.New("invalid cursor")
+	}
+	return values, nil
+}
+
+// filters return the filters of the option, with the seek filter of the cursor if any.
+func (flt Option) filters() Filters {
+	if len(flt.Cursor) == 0 {
+		return flt.Filters
+	}
+	fs := make(Filters, 0, len(flt.Filters)+1)
+	fs = append(fs, flt.Filters...)
+	return append(fs, Seek{Keys: flt.SortKeys(), Values: flt.Cursor, Collation: flt.Collation, Nulls: flt.nulls()})
+}
+
+// NextCursor return the cursor token of the page after last, the last item of the current page.
+// The cursor holds the values of the sort keys of last. For the pages to be stable, the
+// last sort key should be a unique field ('sortBy=name,id').
+func (flt Option) NextCursor(last any) (string, error) {
+	keys := flt.SortKeys()
+	if len(keys) == 0 {
+		return "", errors.New("filter : cursor pagination requires sort keys")
+	}
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		fv, found, err := lookupField(last, key.Field, "")
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "", fmt.Errorf("filter : unknown sort field (%v)", key.Field)
+		}
+		if !fv.IsValid() {
+			return "", fmt.Errorf("filter : null sort field (%v)", key.Field)
+		}
+		values[i], err = formatValue(fv)
+		if err != nil {
+			return "", fmt.Errorf("filter : unsuported field type (%v as %v)", key.Field, fv.Type().String())
+		}
+	}
+	return encodeCursor(values), nil
+}
+
+// formatValue return the compared value v formatted as a filter value.
+func formatValue(v reflect.Value) (string, error) {
+	switch kindOf(v.Type()) {
+	case kindString:
+		return v.String(), nil
+	case kindInt:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case kindUint:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case kindFloat:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case kindBool:
+		return strconv.FormatBool(v.Bool()), nil
+	case kindTime:
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	case kindDuration:
+		return time.Duration(v.Int()).String(), nil
+	default:
+		return "", errors.New("unsupported type")
+	}
+}
+
+// Seek filter to keep the values after a position in the sort order, for keyset
+// pagination. The position is given by the values of the sort keys.
+type Seek struct {
+	Keys   []SortKey
+	Values []string
+	// Collation is the order of the string keys, see Option.Collation.
+	Collation string
+	// Nulls is the position of null values, see Option.Nulls.
+	Nulls string
+}
+
+// nullsAfter return true if the null values of the key are after the position : with nulls last,
+// or for a descending key when null values are lower than any other value.
+func (f Seek) nullsAfter(key SortKey) bool {
+	return keyNulls(f.Nulls, key.Desc) == nullsLast
+}
+
+// collation return the function comparing strings in the order of the collation of the seek
+// filter, nil without collation.
+func (f Seek) collation() func(a, b string) int {
+	if f.Collation == "" {
+		return nil
+	}
+	// an invalid collation is ignored, as by Option.Sort
+	collation, _ := CollationFunc(f.Collation)
+	return collation
+}
+
+// Keep return true if the struct v is after the position in the sort order.
+// Keys not matching a field of the struct are ignored.
+func (f Seek) Keep(v any) (bool, error) {
+	collation := f.collation()
+	for i, key := range f.Keys[:min(len(f.Keys), len(f.Values))] {
+		fv, found, err := lookupField(v, key.Field, "")
+		if err != nil {
+			return false, err
+		}
+		if !found {
+			continue
+		}
+		if !fv.IsValid() {
+			// null value, or nil pointer on the path
+			return f.nullsAfter(key), nil
+		}
+		var c int
+		if collation != nil && kindOf(fv.Type()) == kindString {
+			c = collation(fv.String(), f.Values[i])
+		} else if c, err = compareString(fv, f.Values[i]); err != nil {
+			return false, ErrParamType{key.Field, err}
+		}
+		if c != 0 {
+			return (c > 0) != key.Desc, nil
+		}
+	}
+	return false, nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "(Key1, Key2) > (Value1, Value2)".
+func (f Seek) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "(Key1, Key2) > ($1, $2)". When the keys
+// do not have the same direction, or when the null values of a key are after the position, as
+// for a descending key, the condition is expanded as "(Key1 > $1 OR (Key1 = $2 AND (Key2 < $3
+// OR Key2 IS NULL)))". String keys are compared in the order of the collation, as in
+// Option.BuildSQL.
+func (f Seek) BuildSQL(b *SQLBuilder) string {
+	keys := f.Keys[:min(len(f.Keys), len(f.Values))]
+	if len(keys) == 0 {
+		return "FALSE"
+	}
+	cols := make([]string, len(keys))
+	for i, key := range keys {
+		cols[i] = b.Column(key.Field)
+		if f.Collation != "" && b.isString(key.Field) {
+			cols[i] = b.dialect().Collate(cols[i], f.Collation)
+		}
+	}
+
+	// null values never match a row comparison, which is right only when they are before the position
+	rowComparison := true
+	for _, key := range keys {
+		rowComparison = rowComparison && key.Desc == keys[0].Desc && !f.nullsAfter(key)
+	}
+	if rowComparison {
+		op := ">"
+		if keys[0].Desc {
+			op = "<"
+		}
+		values := make([]string, len(keys))
+		for i := range keys {
+			values[i] = b.FieldValue(keys[i].Field, f.Values[i])
+		}
+		if len(keys) == 1 {
+			return fmt.Sprintf("%s %s %s", cols[0], op, values[0])
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(cols, ", "), op, strings.Join(values, ", "))
+	}
+
+	var or []string
+	for i, key := range keys {
+		var and []string
+		for j := range keys[:i] {
+			and = append(and, fmt.Sprintf("%s = %s", cols[j], b.FieldValue(keys[j].Field, f.Values[j])))
+		}
+		op := ">"
+		if key.Desc {
+			op = "<"
+		}
+		cond := fmt.Sprintf("%s %s %s", cols[i], op, b.FieldValue(key.Field, f.Values[i]))
+		if f.nullsAfter(key) {
+			cond = fmt.Sprintf("(%s OR %s IS NULL)", cond, b.Column(key.Field))
+		}
+		and = append(and, cond)
+		if len(and) == 1 {
+			or = append(or, and[0])
+			continue
+		}
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return "(" + strings.Join(or, " OR ") + ")"
+}
+
+// expand return the filters equivalent to the seek filter, for the query languages without row
+// comparisons : Or{GT{Key1, Value1}, And{EQ{Key1, Value1}, LT{Key2, Value2}}}. The keys whose
+// null values are after the position also keep them : Or{LT{Key1, Value1}, IsNull{Key1, "true"}}.
+// Without keys, it is an empty Or, which keeps nothing.
+func (f Seek) expand() Filter {
+	keys := f.Keys[:min(len(f.Keys), len(f.Values))]
+	or := Or{}
+	for i, key := range keys {
+		var and And
+		for j, prev := range keys[:i] {
+			and = append(and, EQ{prev.Field, f.Values[j]})
+		}
+		var cond Filter = GT{key.Field, f.Values[i]}
+		if key.Desc {
+			cond = LT{key.Field, f.Values[i]}
+		}
+		if f.nullsAfter(key) {
+			cond = Or{cond, IsNull{key.Field, "true"}}
+		}
+		and = append(and, cond)
+		if len(and) == 1 {
+			or = append(or, and[0])
+			continue
+		}
+		or = append(or, and)
+	}
+	return or
+}
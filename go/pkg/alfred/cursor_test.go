@@ -0,0 +1,264 @@
+package alfred
+
+import (
+	"database/sql"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cursorHero struct {
+	ID    int       `filter:"id"`
+	Name  string    `filter:"name"`
+	Team  string    `filter:"team"`
+	Birth time.Time `filter:"birth"`
+}
+
+func TestCursor(t *testing.T) {
+	token := encodeCursor([]string{"jla", "42"})
+	values, err := decodeCursor(token)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"jla", "42"}, values)
+
+	for _, token := range []string{"!", encodeCursor(nil), "bm90IGpzb24"} {
+		_, err := decodeCursor(token)
+		assert.EqualError(t, err, "invalid cursor")
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	hero := cursorHero{ID: 42, Name: "Bruce", Birth: time.Date(1939, time.May, 1, 0, 0, 0, 0, time.UTC)}
+
+	token, err := Option{SortBy: "name,-birth,id"}.NextCursor(hero)
+	assert.NoError(t, err)
+	values, err := decodeCursor(token)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bruce", "1939-05-01T00:00:00Z", "42"}, values)
+
+	_, err = Option{}.NextCursor(hero)
+	assert.Error(t, err)
+	_, err = Option{SortBy: "power"}.NextCursor(hero)
+	assert.Error(t, err)
+}
+
+func TestApplyCursor(t *testing.T) {
+	heroes := []cursorHero{
+		{ID: 1, Name: "Bruce", Team: "jla"},
+		{ID: 2, Name: "Clark", Team: "jla"},
+		{ID: 3, Name: "Tony", Team: "avengers"},
+		{ID: 4, Name: "Diana", Team: "jla"},
+		{ID: 5, Name: "Steve", Team: "avengers"},
+		{ID: 6, Name: "Arthur", Team: "jla"},
+	}
+	tests := map[string]struct {
+		sortBy string
+		want   []int
+	}{
+		"asc":   {sortBy: "team,id", want: []int{3, 5, 1, 2, 4, 6}},
+		"desc":  {sortBy: "-team,-id", want: []int{6, 4, 2, 1, 5, 3}},
+		"mixed": {sortBy: "team,-name,id", want: []int{3, 5, 4, 2, 1, 6}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opt := Option{SortBy: tt.sortBy, Limit: 4}
+			var ids []int
+			for {
+				page, total, err := Apply(heroes, opt)
+				assert.NoError(t, err)
+				assert.Equal(t, len(heroes)-len(ids), total)
+				for _, hero := range page {
+					ids = append(ids, hero.ID)
+				}
+				if len(page) < opt.Limit {
+					break
+				}
+				token, err := opt.NextCursor(page[len(page)-1])
+				assert.NoError(t, err)
+				values, err := url.ParseQuery("cursor=" + token)
+				assert.NoError(t, err)
+				opt.Cursor = ParseURLValues(values).Cursor
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestApplyCursorOrder(t *testing.T) {
+	type nullableHero struct {
+		ID   int     `filter:"id"`
+		Name *string `filter:"name"`
+	}
+	name := func(s string) *string { return &s }
+	heroes := []nullableHero{
+		{ID: 1, Name: name("Zoe")},
+		{ID: 2, Name: name("Eve")},
+		{ID: 3},
+		{ID: 4, Name: name("Émile")},
+	}
+	tests := map[string]struct {
+		opt  Option
+		want []int
+	}{
+		"nulls-last": {opt: Option{SortBy: "name", Collation: "fr", Nulls: "last"}, want: []int{4, 2, 1, 3}},
+		"desc":       {opt: Option{SortBy: "-name", Collation: "fr"}, want: []int{1, 2, 4, 3}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opt := tt.opt
+			opt.Limit = 1
+			var ids []int
+			for {
+				page, _, err := Apply(heroes, opt)
+				assert.NoError(t, err)
+				if !assert.Len(t, page, 1) {
+					break
+				}
+				ids = append(ids, page[0].ID)
+				if page[0].Name == nil {
+					// the null value is the last one
+					break
+				}
+				token, err := opt.NextCursor(page[0])
+				assert.NoError(t, err)
+				opt.Cursor, err = decodeCursor(token)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+
+	structs := []cursorHero{{ID: 1, Name: "Zoe"}, {ID: 2, Name: "Eve"}, {ID: 3, Name: "Åsa"}}
+	opt := Option{SortBy: "name", Collation: "sv", Limit: 1}
+	var ids []int
+	for range structs {
+		page, _, err := Apply(structs, opt)
+		assert.NoError(t, err)
+		if !assert.Len(t, page, 1) {
+			break
+		}
+		ids = append(ids, page[0].ID)
+		token, err := opt.NextCursor(page[0])
+		assert.NoError(t, err)
+		opt.Cursor, err = decodeCursor(token)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []int{2, 1, 3}, ids) // Å after Z in Swedish
+}
+
+func TestSeekBuildSQL(t *testing.T) {
+	tests := map[string]struct {
+		filter Seek
+		want   string
+		args   []any
+	}{
+		"single": {
+			filter: Seek{Keys: []SortKey{{"name", false}}, Values: []string{"Bruce"}},
+			want:   `"name" > $1`,
+			args:   []any{"Bruce"},
+		},
+		"asc": {
+			filter: Seek{Keys: []SortKey{{"name", false}, {"id", false}}, Values: []string{"Bruce", "42"}},
+			want:   `("name", "id") > ($1, $2)`,
+			args:   []any{"Bruce", "42"},
+		},
+		"desc": {
+			filter: Seek{Keys: []SortKey{{"name", true}, {"id", true}}, Values: []string{"Bruce", "42"}},
+			want:   `(("name" < $1 OR "name" IS NULL) OR ("name" = $2 AND ("id" < $3 OR "id" IS NULL)))`,
+			args:   []any{"Bruce", "Bruce", "42"},
+		},
+		"mixed": {
+			filter: Seek{Keys: []SortKey{{"team", false}, {"name", true}, {"id", false}}, Values: []string{"jla", "Bruce", "42"}},
+			want:   `("team" > $1 OR ("team" = $2 AND ("name" < $3 OR "name" IS NULL)) OR ("team" = $4 AND "name" = $5 AND "id" > $6))`,
+			args:   []any{"jla", "jla", "Bruce", "jla", "Bruce", "42"},
+		},
+		"collation": {
+			filter: Seek{Keys: []SortKey{{"name", false}, {"id", false}}, Values: []string{"Bruce", "42"}, Collation: "fr"},
+			want:   `("name" COLLATE "fr-x-icu", "id" COLLATE "fr-x-icu") > ($1, $2)`,
+			args:   []any{"Bruce", "42"},
+		},
+		"nulls-first": {
+			filter: Seek{Keys: []SortKey{{"name", true}}, Values: []string{"Bruce"}, Nulls: "first"},
+			want:   `"name" < $1`,
+			args:   []any{"Bruce"},
+		},
+		"nulls-last": {
+			filter: Seek{Keys: []SortKey{{"name", false}, {"id", true}}, Values: []string{"Bruce", "42"}, Nulls: "last"},
+			want:   `(("name" > $1 OR "name" IS NULL) OR ("name" = $2 AND ("id" < $3 OR "id" IS NULL)))`,
+			args:   []any{"Bruce", "Bruce", "42"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &SQLBuilder{}
+			assert.Equal(t, tt.want, tt.filter.BuildSQL(b))
+			assert.Equal(t, tt.args, b.Args())
+		})
+	}
+}
+
+func TestCursorAddToPSQLQuery(t *testing.T) {
+	opt := Option{SortBy: "name,id", Limit: 10, Cursor: []string{"Bruce", "42"}, Filters: Filters{EQ{"team", "jla"}}}
+	want := `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE "team" = 'jla' AND ("name", "id") > ('Bruce', '42') ORDER BY "name" ASC NULLS FIRST, "id" ASC NULLS FIRST LIMIT 10 OFFSET 0`
+	assert.Equal(t, want, AddToPSQLQuery("SELECT * FROM heroes", opt))
+}
+
+func TestValidateCursor(t *testing.T) {
+	schema := NewSchema(cursorHero{})
+	assert.NoError(t, Option{SortBy: "name,id", Cursor: []string{"Bruce", "42"}}.Validate(schema))
+	assert.Equal(t, ErrValidation{{Param: "cursor", Value: encodeCursor([]string{"Bruce"}), Reason: "cursor does not match sort keys"}},
+		Option{SortBy: "name,id", Cursor: []string{"Bruce"}}.Validate(schema))
+	assert.Equal(t, ErrValidation{{Param: "cursor", Value: encodeCursor([]string{"Bruce", "x"}), Reason: "invalid int value"}},
+		Option{SortBy: "name,id", Cursor: []string{"Bruce", "x"}}.Validate(schema))
+	assert.Equal(t, ErrValidation{{Param: "cursor", Value: "!", Reason: "invalid cursor"}},
+		ParseURLValues(url.Values{"cursor": {"!"}}).Validate(schema))
+}
+
+func TestSQLiteCursorNulls(t *testing.T) {
+	type hero struct {
+		ID   int     `filter:"id"`
+		Team *string `filter:"team"`
+	}
+	heroes := []hero{{1, ptr("jla")}, {2, nil}, {3, ptr("avengers")}, {4, nil}, {5, ptr("jla")}}
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE heroes (id INTEGER PRIMARY KEY, team TEXT)`)
+	require.NoError(t, err)
+	for _, h := range heroes {
+		_, err = db.Exec(`INSERT INTO heroes VALUES (?, ?)`, h.ID, h.Team)
+		require.NoError(t, err)
+	}
+
+	// the same cursor goes through the null values in memory and in SQL
+	for _, opt := range []Option{
+		{SortBy: "-team,id", Cursor: []string{"jla", "5"}},
+		{SortBy: "-team,id", Cursor: []string{"avengers", "3"}},
+		{SortBy: "team,-id", Cursor: []string{"avengers", "3"}},
+		{SortBy: "-team,-id", Cursor: []string{"jla", "1"}, Nulls: "first"},
+		{SortBy: "team,id", Cursor: []string{"jla", "1"}, Nulls: "last"},
+	} {
+		b := &SQLBuilder{Dialect: SQLite{}, Schema: NewSchema(hero{})}
+		query := b.Query("SELECT * FROM heroes", opt)
+		rows, err := db.Query(query, b.Args()...)
+		require.NoError(t, err)
+		var ids []int
+		for rows.Next() {
+			var h hero
+			var total int
+			require.NoError(t, rows.Scan(&h.ID, &h.Team, &total))
+			ids = append(ids, h.ID)
+		}
+		require.NoError(t, rows.Close())
+
+		page, _, err := Apply(heroes, opt)
+		require.NoError(t, err)
+		var want []int
+		for _, h := range page {
+			want = append(want, h.ID)
+		}
+		assert.Equal(t, want, ids, query)
+	}
+}
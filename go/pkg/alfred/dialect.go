@@ -0,0 +1,311 @@
+package alfred
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"golang.org/x/text/language"
+)
+
+// Dialect renders the SQL constructs that differ between databases.
+type Dialect interface {
+	// QuoteIdent return the quoted identifier name.
+	QuoteIdent(name string) string
+	// QuoteLiteral return the quoted string literal s.
+	QuoteLiteral(s string) string
+	// Placeholder return the placeholder of the n-th query argument, starting at 1.
+	Placeholder(n int) string
+	// Path return the column of a nested field, from the path of its names. With json, the
+	// first name is a JSON document column and the others the path in the document.
+	Path(names []string, json bool) string
+	// ILike return a case insensitive pattern matching condition, the wildcards of pattern being
+	// escaped by '\' (see escapeLike).
+	ILike(col, pattern string) string
+	// Contains return a condition testing if the comma separated list col contains value.
+	Contains(col, value string) string
+	// ArrayContains return a condition testing if the array col contains all the values.
+	ArrayContains(col string, values []string) string
+	// ArrayOverlaps return a condition testing if the array col contains any of the values.
+	ArrayOverlaps(col string, values []string) string
+	// Regex return a regular expression matching condition.
+	Regex(col, pattern string) string
+	// NoLimit return the LIMIT value for no limit.
+	NoLimit() string
+	// Collate return the column col ordered by the collation, a BCP 47 language tag.
+	Collate(col, collation string) string
+	// OrderBy return the ORDER BY item of col, with null values first or last if nulls is
+	// 'first' or 'last', at the database default position if empty.
+	OrderBy(col string, desc bool, nulls string) string
+}
+
+// Postgres is the PostgreSQL dialect.
+type Postgres struct{}
+
+// QuoteIdent return the quoted identifier name : "name".
+func (Postgres) QuoteIdent(name string) string {
+	return pq.QuoteIdentifier(name)
+}
+
+// QuoteLiteral return the quoted string literal s : 's'.
+func (Postgres) QuoteLiteral(s string) string {
+	return pq.QuoteLiteral(s)
+}
+
+// Placeholder return the placeholder of the n-th query argument : $n.
+func (Postgres) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Path return a composite type field ("address")."city", or a JSON path "address"->>'city'.
+func (d Postgres) Path(names []string, json bool) string {
+	col := d.QuoteIdent(names[0])
+	for i, name := range names[1:] {
+		switch {
+		case !json:
+			col = "(" + col + ")." + d.QuoteIdent(name)
+		case i == len(names)-2:
+			col += "->>" + d.QuoteLiteral(name)
+		default:
+			col += "->" + d.QuoteLiteral(name)
+		}
+	}
+	return col
+}
+
+// ILike return the condition : col ILIKE pattern ESCAPE '\'.
+func (Postgres) ILike(col, pattern string) string {
+	return col + " ILIKE " + pattern + ` ESCAPE '\'`
+}
+
+// Contains return the condition : string_to_array(col, ',') @> ARRAY[value].
+func (Postgres) Contains(col, value string) string {
+	return "string_to_array(" + col + ", ',') @> ARRAY[" + value + "]"
+}
+
+// ArrayContains return the condition : col @> ARRAY[value1, value2, ...].
+func (Postgres) ArrayContains(col string, values []string) string {
+	return col + " @> ARRAY[" + strings.Join(values, ", ") + "]"
+}
+
+// ArrayOverlaps return the condition : col && ARRAY[value1, value2, ...].
+func (Postgres) ArrayOverlaps(col string, values []string) string {
+	return col + " && ARRAY[" + strings.Join(values, ", ") + "]"
+}
+
+// Regex return the condition : col ~ pattern.
+func (Postgres) Regex(col, pattern string) string {
+	return col + " ~ " + pattern
+}
+
+// NoLimit return ALL.
+func (Postgres) NoLimit() string {
+	return "ALL"
+}
+
+// Collate return the column ordered by the ICU collation of the language tag : col COLLATE "fr-x-icu".
+func (d Postgres) Collate(col, collation string) string {
+	return col + " COLLATE " + d.QuoteIdent(collation+"-x-icu")
+}
+
+// OrderBy return the ORDER BY item : col ASC|DESC [NULLS FIRST|LAST].
+func (Postgres) OrderBy(col string, desc bool, nulls string) string {
+	return orderByNulls(col, desc, nulls)
+}
+
+// SQLite is the SQLite dialect.
+type SQLite struct{}
+
+// QuoteIdent return the quoted identifier name : "name".
+func (SQLite) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteLiteral return the quoted string literal s : 's'.
+func (SQLite) QuoteLiteral(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `''`) + `'`
+}
+
+// Placeholder return the placeholder of the n-th query argument : ?.
+func (SQLite) Placeholder(int) string {
+	return "?"
+}
+
+// Path return a qualified column "address"."city", or a JSON path json_extract("address", '$.city').
+func (d SQLite) Path(names []string, json bool) string {
+	if json {
+		return "json_extract(" + d.QuoteIdent(names[0]) + ", " + d.QuoteLiteral(jsonPath(names[1:])) + ")"
+	}
+	return qualifiedIdent(d, names)
+}
+
+// ILike return the condition : col LIKE pattern ESCAPE '\'. SQLite LIKE is case insensitive for ASCII characters.
+func (SQLite) ILike(col, pattern string) string {
+	return col + " LIKE " + pattern + ` ESCAPE '\'`
+}
+
+// Contains return the condition : instr(',' || col || ',', ',' || value || ',') > 0.
+func (SQLite) Contains(col, value string) string {
+	return "instr(',' || " + col + " || ',', ',' || " + value + " || ',') > 0"
+}
+
+// ArrayContains return the condition on the JSON array col :
+// (EXISTS (SELECT 1 FROM json_each(col) WHERE value = value1) AND ...).
+func (SQLite) ArrayContains(col string, values []string) string {
+	conds := make([]string, len(values))
+	for i, value := range values {
+		conds[i] = "EXISTS (SELECT 1 FROM json_each(" + col + ") WHERE value = " + value + ")"
+	}
+	return "(" + strings.Join(conds, " AND ") + ")"
+}
+
+// ArrayOverlaps return the condition on the JSON array col :
+// EXISTS (SELECT 1 FROM json_each(col) WHERE value IN (value1, value2, ...)).
+func (SQLite) ArrayOverlaps(col string, values []string) string {
+	return "EXISTS (SELECT 1 FROM json_each(" + col + ") WHERE value IN (" + strings.Join(values, ", ") + "))"
+}
+
+// Regex return the condition : col REGEXP pattern. SQLite requires a user defined regexp() function.
+func (SQLite) Regex(col, pattern string) string {
+	return col + " REGEXP " + pattern
+}
+
+// NoLimit return -1.
+func (SQLite) NoLimit() string {
+	return "-1"
+}
+
+// Collate return the column ordered by the collation named after the language tag : col COLLATE "fr".
+// SQLite requires a user defined collation, see CollationFunc.
+func (d SQLite) Collate(col, collation string) string {
+	return col + " COLLATE " + d.QuoteIdent(collation)
+}
+
+// OrderBy return the ORDER BY item : col ASC|DESC [NULLS FIRST|LAST].
+func (SQLite) OrderBy(col string, desc bool, nulls string) string {
+	return orderByNulls(col, desc, nulls)
+}
+
+// MySQL is the MySQL dialect.
+type MySQL struct{}
+
+// QuoteIdent return the quoted identifier name : `name`.
+func (MySQL) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// QuoteLiteral return the quoted string literal s : 's'.
+func (MySQL) QuoteLiteral(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `'` + strings.ReplaceAll(s, `'`, `''`) + `'`
+}
+
+// Placeholder return the placeholder of the n-th query argument : ?.
+func (MySQL) Placeholder(int) string {
+	return "?"
+}
+
+// Path return a qualified column `address`.`city`, or a JSON path `address`->>'$.city'.
+func (d MySQL) Path(names []string, json bool) string {
+	if json {
+		return d.QuoteIdent(names[0]) + "->>" + d.QuoteLiteral(jsonPath(names[1:]))
+	}
+	return qualifiedIdent(d, names)
+}
+
+// ILike return the condition : LOWER(col) LIKE LOWER(pattern) ESCAPE '\\', the backslash being
+// escaped in MySQL string literals.
+func (MySQL) ILike(col, pattern string) string {
+	return "LOWER(" + col + ") LIKE LOWER(" + pattern + `) ESCAPE '\\'`
+}
+
+// Contains return the condition : FIND_IN_SET(value, col) > 0.
+func (MySQL) Contains(col, value string) string {
+	return "FIND_IN_SET(" + value + ", " + col + ") > 0"
+}
+
+// ArrayContains return the condition on the JSON array col : JSON_CONTAINS(col, JSON_ARRAY(value1, value2, ...)).
+func (MySQL) ArrayContains(col string, values []string) string {
+	return "JSON_CONTAINS(" + col + ", JSON_ARRAY(" + strings.Join(values, ", ") + "))"
+}
+
+// ArrayOverlaps return the condition on the JSON array col : JSON_OVERLAPS(col, JSON_ARRAY(value1, value2, ...)).
+func (MySQL) ArrayOverlaps(col string, values []string) string {
+	return "JSON_OVERLAPS(" + col + ", JSON_ARRAY(" + strings.Join(values, ", ") + "))"
+}
+
+// Regex return the condition : col REGEXP pattern.
+func (MySQL) Regex(col, pattern string) string {
+	return col + " REGEXP " + pattern
+}
+
+// NoLimit return the maximum value of the LIMIT clause.
+func (MySQL) NoLimit() string {
+	return "18446744073709551615"
+}
+
+// mysqlCollations are the languages with a specific utf8mb4 collation in MySQL.
+var mysqlCollations = map[string]string{
+	"bs": "bs", "cs": "cs", "da": "da", "de": "de_pb", "eo": "eo", "es": "es", "et": "et", "gl": "gl",
+	"hr": "hr", "hu": "hu", "is": "is", "ja": "ja", "la": "la", "lt": "lt", "lv": "lv", "mn": "mn_cyrl",
+	"pl": "pl", "ro": "ro", "ru": "ru", "sk": "sk", "sl": "sl", "sv": "sv", "tr": "tr", "vi": "vi", "zh": "zh",
+}
+
+// Collate return the column ordered by the utf8mb4 collation of the language of the tag :
+// col COLLATE utf8mb4_<language>_0900_ai_ci, or col COLLATE utf8mb4_0900_ai_ci for the other languages.
+func (MySQL) Collate(col, collation string) string {
+	name := "utf8mb4_0900_ai_ci"
+	if tag, err := language.Parse(collation); err == nil {
+		base, _ := tag.Base()
+		if lang, ok := mysqlCollations[base.String()]; ok {
+			name = "utf8mb4_" + lang + "_0900_ai_ci"
+		}
+	}
+	return col + " COLLATE " + name
+}
+
+// OrderBy return the ORDER BY item : col ASC|DESC, prefixed with col IS NULL DESC for null values
+// first, or col IS NULL ASC for null values last.
+func (MySQL) OrderBy(col string, desc bool, nulls string) string {
+	item := col + " ASC"
+	if desc {
+		item = col + " DESC"
+	}
+	switch nulls {
+	case nullsFirst:
+		return col + " IS NULL DESC, " + item
+	case nullsLast:
+		return col + " IS NULL ASC, " + item
+	default:
+		return item
+	}
+}
+
+// orderByNulls return the ORDER BY item : col ASC|DESC [NULLS FIRST|LAST].
+func orderByNulls(col string, desc bool, nulls string) string {
+	item := col + " ASC"
+	if desc {
+		item = col + " DESC"
+	}
+	switch nulls {
+	case nullsFirst:
+		return item + " NULLS FIRST"
+	case nullsLast:
+		return item + " NULLS LAST"
+	default:
+		return item
+	}
+}
+
+func qualifiedIdent(d Dialect, names []string) string {
+	idents := make([]string, len(names))
+	for i, name := range names {
+		idents[i] = d.QuoteIdent(name)
+	}
+	return strings.Join(idents, ".")
+}
+
+// jsonPath return the JSON path of the names : $.name1.name2.
+func jsonPath(names []string) string {
+	return "$." + strings.Join(names, ".")
+}
@@ -0,0 +1,171 @@
+package alfred
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialects(t *testing.T) {
+	opt := Option{
+		Limit:   10,
+		SortBy:  "name",
+		Filters: Filters{Like{"name", "bat"}, Contain{"tags", "rich"}, EQ{"address.city", "Gotham"}},
+	}
+	tests := map[string]struct {
+		builder *SQLBuilder
+		want    string
+		args    []any
+	}{
+		"postgres": {
+			builder: &SQLBuilder{Dialect: Postgres{}, JSONPath: true},
+			want:    `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE "name" ILIKE $1 ESCAPE '\' AND string_to_array("tags", ',') @> ARRAY[$2] AND "address"->>'city' = $3 ORDER BY "name" ASC NULLS FIRST LIMIT $4 OFFSET $5`,
+		},
+		"sqlite": {
+			builder: &SQLBuilder{Dialect: SQLite{}, JSONPath: true},
+			want:    `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE "name" LIKE ? ESCAPE '\' AND instr(',' || "tags" || ',', ',' || ? || ',') > 0 AND json_extract("address", '$.city') = ? ORDER BY "name" ASC NULLS FIRST LIMIT ? OFFSET ?`,
+		},
+		"mysql": {
+			builder: &SQLBuilder{Dialect: MySQL{}, JSONPath: true},
+			want:    "SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE LOWER(`name`) LIKE LOWER(?) ESCAPE '\\\\' AND FIND_IN_SET(?, `tags`) > 0 AND `address`->>'$.city' = ? ORDER BY `name` IS NULL DESC, `name` ASC LIMIT ? OFFSET ?",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.builder.Query("SELECT * FROM heroes", opt))
+			assert.Equal(t, []any{"%bat%", "rich", "Gotham", 10, 0}, tt.builder.Args())
+		})
+	}
+}
+
+func TestDialectQuote(t *testing.T) {
+	tests := map[string]struct {
+		dialect Dialect
+		ident   string
+		literal string
+		path    string
+		noLimit string
+	}{
+		"postgres": {dialect: Postgres{}, ident: `"a""b"`, literal: `'it''s'`, path: `("a")."b"`, noLimit: "ALL"},
+		"sqlite":   {dialect: SQLite{}, ident: `"a""b"`, literal: `'it''s'`, path: `"a"."b"`, noLimit: "-1"},
+		"mysql":    {dialect: MySQL{}, ident: "`a\"b`", literal: `'it''s'`, path: "`a`.`b`", noLimit: "18446744073709551615"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.ident, tt.dialect.QuoteIdent(`a"b`))
+			assert.Equal(t, tt.literal, tt.dialect.QuoteLiteral(`it's`))
+			assert.Equal(t, tt.path, tt.dialect.Path([]string{"a", "b"}, false))
+			assert.Equal(t, tt.noLimit, tt.dialect.NoLimit())
+		})
+	}
+	assert.Equal(t, `'a\\b'`, MySQL{}.QuoteLiteral(`a\b`))
+}
+
+type sqliteHero struct {
+	ID   int    `filter:"id"`
+	Name string `filter:"name"`
+	Team string `filter:"team"`
+	Age  int    `filter:"age"`
+	Tags string `filter:"tags"`
+}
+
+var sqliteHeroes = []sqliteHero{
+	{ID: 1, Name: "Bruce", Team: "jla", Age: 39, Tags: "rich,detective"},
+	{ID: 2, Name: "Clark", Team: "jla", Age: 35, Tags: "alien,flight"},
+	{ID: 3, Name: "Diana", Team: "jla", Age: 5000, Tags: "amazon,flight"},
+	{ID: 4, Name: "Tony", Team: "avengers", Age: 48, Tags: "rich,genius"},
+	{ID: 5, Name: "Steve", Team: "avengers", Age: 105, Tags: "soldier"},
+	{ID: 6, Name: "Barry", Team: "jla", Age: 28, Tags: "speed"},
+}
+
+func init() {
+	sql.Register("sqlite3_regexp", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("regexp", regexp.MatchString, true)
+		},
+	})
+}
+
+// openSQLite return an in memory SQLite database with the heroes table.
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3_regexp", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE heroes (id INTEGER PRIMARY KEY, name TEXT, team TEXT, age INTEGER, tags TEXT)`)
+	require.NoError(t, err)
+	for _, h := range sqliteHeroes {
+		_, err = db.Exec(`INSERT INTO heroes VALUES (?, ?, ?, ?, ?)`, h.ID, h.Name, h.Team, h.Age, h.Tags)
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func TestSQLiteQuery(t *testing.T) {
+	db := openSQLite(t)
+	tests := map[string]Option{
+		"all":       {},
+		"like":      {Filters: Filters{Like{"name", "AR"}}},
+		"like-_":    {Filters: Filters{Like{"name", "_"}}},
+		"like-%":    {Filters: Filters{Like{"tags", "%"}}},
+		"eq":        {Filters: Filters{EQ{"team", "avengers"}}},
+		"gt":        {Filters: Filters{GT{"age", "40"}}},
+		"lte":       {Filters: Filters{LTE{"age", "39"}}, SortBy: "-age"},
+		"contain":   {Filters: Filters{Contain{"tags", "flight"}}},
+		"or-not":    {Filters: Filters{Or{EQ{"team", "avengers"}, Not{GT{"age", "30"}}}}},
+		"sort":      {SortBy: "team,-name"},
+		"page":      {SortBy: "name", Limit: 2, Offset: 1},
+		"offset":    {SortBy: "name", Offset: 4},
+		"cursor":    {SortBy: "team,id", Limit: 2, Cursor: []string{"jla", "2"}},
+		"cursor-d":  {SortBy: "team,-name", Cursor: []string{"jla", "Clark"}},
+		"ne":        {Filters: Filters{NE{"team", "jla"}}},
+		"in":        {Filters: Filters{In{"name", []string{"Bruce", "Tony", "Logan"}}}},
+		"nin":       {Filters: Filters{NotIn{"id", []string{"1", "2", "3"}}}},
+		"between":   {Filters: Filters{Between{"age", "35", "48"}}},
+		"isnull":    {Filters: Filters{IsNull{"name", "false"}}},
+		"prefix":    {Filters: Filters{StartsWith{"name", "b"}}},
+		"prefix-_":  {Filters: Filters{StartsWith{"name", "_"}}},
+		"search-%":  {Filters: Filters{Search{Value: "%", Fields: []string{"name"}}}},
+		"contain-%": {Filters: Filters{Contain{"tags", "%"}}},
+		"regex":     {Filters: Filters{Regex{"tags", "^(rich|speed)"}}},
+		"all-tags":  {Filters: Filters{ContainsAll{"tags", []string{"rich", "genius"}}}},
+		"any-tags":  {Filters: Filters{Overlaps{"tags", []string{"rich", "speed"}}}},
+	}
+	for name, opt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, args := BuildQuery(SQLite{}, "SELECT * FROM heroes", opt)
+			rows, err := db.Query(query, args...)
+			require.NoError(t, err)
+			defer rows.Close()
+
+			var ids []int
+			total := 0
+			for rows.Next() {
+				var h sqliteHero
+				require.NoError(t, rows.Scan(&h.ID, &h.Name, &h.Team, &h.Age, &h.Tags, &total))
+				ids = append(ids, h.ID)
+			}
+			require.NoError(t, rows.Err())
+
+			page, wantTotal, err := Apply(sqliteHeroes, opt)
+			require.NoError(t, err)
+			var want []int
+			for _, h := range page {
+				want = append(want, h.ID)
+			}
+			if len(want) > 0 {
+				assert.Equal(t, wantTotal, total)
+			}
+			if opt.SortBy == "" {
+				assert.ElementsMatch(t, want, ids)
+			} else {
+				assert.Equal(t, want, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package alfred
+
+import (
+	"cmp"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/text/collate"
+)
+
+var rawMessageType = reflect.TypeFor[json.RawMessage]()
+
+// isDocument return true if values of type t are documents : maps with string keys,
+// or raw JSON documents ([]byte or json.RawMessage).
+func isDocument(t reflect.Type) bool {
+	switch {
+	case t.Kind() == reflect.Map:
+		return t.Key().Kind() == reflect.String
+	case t == rawMessageType:
+		return true
+	default:
+		return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+	}
+}
+
+// lookupDocument return the compared value of the field at path of the document rv, see isDocument.
+// Fields of raw JSON documents are addressed with the gjson path syntax ('address.city', 'tags.0'),
+// and fields of maps with a dot separated path of keys and slice indexes ('address.city', 'tags.0').
+// The value is invalid if the field is missing or null. See documentValue for the coercion rules.
+func lookupDocument(rv reflect.Value, path string) reflect.Value {
+	if rv.Kind() == reflect.Slice {
+		r := gjson.GetBytes(rv.Bytes(), path)
+		if !r.Exists() {
+			return reflect.Value{}
+		}
+		return documentValue(r.Value())
+	}
+	for _, name := range strings.Split(path, ".") {
+		for rv.Kind() == reflect.Interface || rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}
+			}
+			rv = rv.Elem()
+		}
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}
+			}
+			rv = rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 || i >= rv.Len() {
+				return reflect.Value{}
+			}
+			rv = rv.Index(i)
+		default:
+			return reflect.Value{}
+		}
+		if !rv.IsValid() {
+			return reflect.Value{}
+		}
+	}
+	if (rv.Kind() == reflect.Interface || rv.Kind() == reflect.Pointer) && rv.IsNil() {
+		return reflect.Value{}
+	}
+	if rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	return documentValue(rv.Interface())
+}
+
+// documentValue return the compared value of a value of a document. JSON numbers are float64,
+// strings in the time.RFC3339 format are time.Time, and arrays whose elements are all strings,
+// numbers or booleans are []string, []float64 or []bool. Other values are compared as fields of
+// structs (see scalarOf), null values being invalid.
+func documentValue(x any) reflect.Value {
+	switch x := x.(type) {
+	case nil:
+		return reflect.Value{}
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return reflect.ValueOf(x.String())
+		}
+		return reflect.ValueOf(f)
+	case string:
+		if t, err := time.Parse(time.RFC3339, x); err == nil {
+			return reflect.ValueOf(t)
+		}
+		return reflect.ValueOf(x)
+	case []any:
+		return documentList(x)
+	default:
+		v, _ := scalar(reflect.ValueOf(x))
+		return v
+	}
+}
+
+// documentList return the array as a []string, []float64 or []bool if all its elements are
+// of the same type, and as is otherwise.
+func documentList(a []any) reflect.Value {
+	var t reflect.Type
+	values := make([]reflect.Value, len(a))
+	for i, x := range a {
+		if n, ok := x.(json.Number); ok {
+			x = documentValue(n).Interface()
+		}
+		v := reflect.ValueOf(x)
+		switch {
+		case x == nil:
+			return reflect.ValueOf(a)
+		case t == nil:
+			t = v.Type()
+		case v.Type() != t:
+			return reflect.ValueOf(a)
+		}
+		values[i] = v
+	}
+	if t == nil {
+		return reflect.ValueOf([]string{})
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Float64, reflect.Bool:
+	default:
+		return reflect.ValueOf(a)
+	}
+	list := reflect.MakeSlice(reflect.SliceOf(t), len(values), len(values))
+	for i, v := range values {
+		list.Index(i).Set(v)
+	}
+	return list
+}
+
+// compareDynamic return -1, 0 or +1 depending on whether a is less than, equal to, or greater than b,
+// two values of documents of any type. Numbers are compared as float64, strings with the collator if
+// not nil, and values of different kinds are ordered by kind : bools, numbers, strings, times, durations.
+// Values of unsupported kinds are equal.
+func compareDynamic(a, b reflect.Value, collator *collate.Collator) int {
+	ka, kb := dynamicKind(kindOf(a.Type())), dynamicKind(kindOf(b.Type()))
+	if ka != kb {
+		return cmp.Compare(ka, kb)
+	}
+	switch ka {
+	case dynamicNumber:
+		return cmp.Compare(toFloat(a), toFloat(b))
+	case dynamicString:
+		if collator != nil {
+			return collator.CompareString(a.String(), b.String())
+		}
+		return compareStrings(a.String(), b.String())
+	case dynamicOther:
+		return 0
+	default:
+		return comparator(a.Type())(a, b)
+	}
+}
+
+// the order of the kinds of values of documents
+const (
+	dynamicBool = iota
+	dynamicNumber
+	dynamicString
+	dynamicTime
+	dynamicDuration
+	dynamicOther
+)
+
+// dynamicKind return the order of the kind k of a value of a document, numbers being of the same kind.
+func dynamicKind(k valueKind) int {
+	switch k {
+	case kindBool:
+		return dynamicBool
+	case kindInt, kindUint, kindFloat:
+		return dynamicNumber
+	case kindString:
+		return dynamicString
+	case kindTime:
+		return dynamicTime
+	case kindDuration:
+		return dynamicDuration
+	default:
+		return dynamicOther
+	}
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch kindOf(v.Type()) {
+	case kindInt:
+		return float64(v.Int())
+	case kindUint:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
@@ -0,0 +1,170 @@
+package alfred
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const documentBruce = `{
+	"name": "Bruce",
+	"age": 42,
+	"alive": true,
+	"birth": "1939-05-01T00:00:00Z",
+	"address": {"city": "Gotham", "zip": null},
+	"tags": ["bat", "rich"],
+	"scores": [3, 1.5],
+	"mixed": [1, "a"]
+}`
+
+func documentMap(t *testing.T, s string) map[string]any {
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(s), &m))
+	return m
+}
+
+func TestKeepDocument(t *testing.T) {
+	documents := map[string]any{
+		"map":     documentMap(t, documentBruce),
+		"bytes":   []byte(documentBruce),
+		"raw":     json.RawMessage(documentBruce),
+		"pointer": ptr(documentMap(t, documentBruce)),
+	}
+	tests := map[string]struct {
+		filter Filter
+		want   bool
+	}{
+		"eq":               {filter: EQ{"name", "Bruce"}, want: true},
+		"eq-drop":          {filter: EQ{"name", "Clark"}, want: false},
+		"like":             {filter: Like{"name", "ruc"}, want: true},
+		"number":           {filter: GT{"age", "40"}, want: true},
+		"number-float":     {filter: LT{"age", "42.5"}, want: true},
+		"number-drop":      {filter: GTE{"age", "43"}, want: false},
+		"bool":             {filter: EQ{"alive", "true"}, want: true},
+		"time":             {filter: LT{"birth", "1940-01-01"}, want: true},
+		"time-relative":    {filter: GT{"birth", "now-7d"}, want: false},
+		"between":          {filter: Between{"age", "40", "50"}, want: true},
+		"in":               {filter: In{"address.city", []string{"Gotham", "Metropolis"}}, want: true},
+		"nested":           {filter: EQ{"address.city", "Gotham"}, want: true},
+		"index":            {filter: EQ{"tags.1", "rich"}, want: true},
+		"index-out":        {filter: EQ{"tags.2", "rich"}, want: false},
+		"contain":          {filter: Contain{"tags", "bat"}, want: true},
+		"contains-all":     {filter: ContainsAll{"tags", []string{"bat", "rich"}}, want: true},
+		"contains-numbers": {filter: ContainsAll{"scores", []string{"1.5", "3"}}, want: true},
+		"missing":          {filter: EQ{"unknown", "x"}, want: false},
+		"missing-not":      {filter: Not{EQ{"unknown", "x"}}, want: false},
+		"missing-null":     {filter: IsNull{"address.country", "true"}, want: true},
+		"null":             {filter: IsNull{"address.zip", "true"}, want: true},
+		"not-null":         {filter: IsNull{"name", "false"}, want: true},
+	}
+	for doc, data := range documents {
+		for name, tt := range tests {
+			t.Run(doc+"/"+name, func(t *testing.T) {
+				actual, err := Filters{tt.filter}.Keep(data)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, actual)
+			})
+		}
+	}
+}
+
+func TestKeepDocumentErrors(t *testing.T) {
+	_, err := GT{"name", "x"}.Keep(map[string]any{"name": map[string]any{}})
+	assert.Error(t, err)
+	_, err = Contain{"mixed", "a"}.Keep([]byte(documentBruce))
+	assert.Error(t, err)
+}
+
+func TestSortDocuments(t *testing.T) {
+	docs := []map[string]any{
+		{"id": 1, "name": "diana", "age": 30.0},
+		{"id": 2, "name": "Bruce", "age": 42},
+		{"id": 3, "name": "clark"},
+		{"id": 4, "name": "Barry", "age": "unknown"},
+		{"id": 5, "name": "arthur", "age": 30, "team": map[string]any{"name": "jla"}},
+	}
+	tests := map[string]struct {
+		opt  Option
+		want []int
+	}{
+		"string":         {opt: Option{SortBy: "name"}, want: []int{5, 4, 2, 3, 1}},
+		"number-desc":    {opt: Option{SortBy: "-age,id"}, want: []int{4, 2, 1, 5, 3}},
+		"numbers-stable": {opt: Option{SortBy: "age"}, want: []int{3, 1, 5, 2, 4}},
+		"nulls-last":     {opt: Option{SortBy: "team.name", Nulls: "last"}, want: []int{5, 1, 2, 3, 4}},
+		"collation":      {opt: Option{SortBy: "-name", Collation: "en"}, want: []int{1, 3, 2, 4, 5}},
+		"none":           {opt: Option{}, want: []int{1, 2, 3, 4, 5}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sorted := append([]map[string]any(nil), docs...)
+			tt.opt.Sort(sorted)
+			ids := make([]int, len(sorted))
+			for i, doc := range sorted {
+				ids[i] = doc["id"].(int)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestSortRawDocuments(t *testing.T) {
+	docs := []json.RawMessage{
+		json.RawMessage(`{"name": "Clark", "born": "1938-04-18T00:00:00Z"}`),
+		json.RawMessage(`{"name": "Bruce", "born": "1939-05-01T00:00:00Z"}`),
+		json.RawMessage(`{"name": "Diana"}`),
+		json.RawMessage(`not json`),
+	}
+	Option{SortBy: "-born"}.Sort(docs)
+	var names []string
+	for _, doc := range docs {
+		var v struct{ Name string }
+		_ = json.Unmarshal(doc, &v)
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{"Bruce", "Clark", "Diana", ""}, names)
+}
+
+func TestApplyDocuments(t *testing.T) {
+	docs := []map[string]any{
+		{"name": "Bruce", "team": "jla", "joined": "2024-01-01T00:00:00Z"},
+		{"name": "Clark", "team": "jla", "joined": "2020-01-01T00:00:00Z"},
+		{"name": "Kara", "team": "jsa", "joined": "2024-03-01T00:00:00Z"},
+		{"name": "Diana", "team": "jla", "joined": "2024-02-01T00:00:00Z"},
+	}
+	page, total, err := Apply(docs, Option{
+		Filters: Filters{EQ{"team", "jla"}, GTE{"joined", "2023-01-01"}},
+		SortBy:  "-joined",
+		Limit:   1,
+		Now:     time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []map[string]any{docs[3]}, page)
+}
+
+func TestCompareDynamic(t *testing.T) {
+	now := time.Now()
+	tests := map[string]struct {
+		a, b any
+		want int
+	}{
+		"numbers":      {a: 1, b: 1.5, want: -1},
+		"numbers-uint": {a: uint(2), b: 1.5, want: 1},
+		"numbers-eq":   {a: 2, b: 2.0, want: 0},
+		"strings":      {a: "b", b: "a", want: 1},
+		"bool-number":  {a: true, b: 0, want: -1},
+		"number-str":   {a: 10, b: "1", want: -1},
+		"str-time":     {a: "z", b: now, want: -1},
+		"times":        {a: now, b: now.Add(time.Second), want: -1},
+		"unsupported":  {a: []int{1}, b: []int{2}, want: 0},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, b := documentValue(tt.a), documentValue(tt.b)
+			assert.Equal(t, tt.want, compareDynamic(a, b, nil))
+		})
+	}
+}
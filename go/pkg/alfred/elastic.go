@@ -0,0 +1,260 @@
+package alfred
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ElasticBuilder builds Elasticsearch search requests.
+type ElasticBuilder struct {
+	// Schema of the documents, see MongoBuilder.Schema.
+	Schema *Schema
+	// Keyword is the suffix of the keyword sub-field of the string fields of the schema ('.keyword'),
+	// used for the exact, pattern and prefix matching and the sorting of the text fields.
+	Keyword string
+}
+
+// BuildElasticQuery return the Elasticsearch search request of the option, see ElasticBuilder.Query.
+func BuildElasticQuery(opt Option) (map[string]any, error) {
+	return (&ElasticBuilder{}).Query(opt)
+}
+
+// Query return the body of the Elasticsearch search request filtered, sorted and paginated according to
+// opt, with the same semantics as the SQL query of opt : the filters are a bool query, and the sort, from,
+// size and _source fields are set from the sort keys, the pagination and the fields of opt. Strings are
+// sorted by the keyword sub-fields, the Collation of opt is not supported. The body is encoded with
+// encoding/json.
+func (b *ElasticBuilder) Query(opt Option) (map[string]any, error) {
+	s := newStoreSchema(b.Schema, opt)
+	var filter []any
+	for _, f := range s.filters(opt) {
+		clause, err := b.clause(s, f)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, clause)
+	}
+	body := map[string]any{"query": elasticBool("filter", filter)}
+
+	var sort []any
+	for _, key := range s.sortKeys(opt) {
+		order := "asc"
+		if key.Desc {
+			order = "desc"
+		}
+		missing := "_" + keyNulls(opt.nulls(), key.Desc)
+		sort = append(sort, map[string]any{b.keyword(s, key.Field): map[string]any{"order": order, "missing": missing}})
+	}
+	if len(sort) > 0 {
+		body["sort"] = sort
+	}
+	if opt.Offset > 0 {
+		body["from"] = opt.Offset
+	}
+	if opt.Limit > 0 {
+		body["size"] = opt.Limit
+	}
+	if len(opt.Fields) > 0 {
+		source := make([]string, len(opt.Fields))
+		for i, field := range opt.Fields {
+			source[i] = s.field(field)
+		}
+		body["_source"] = source
+	}
+	return body, nil
+}
+
+// Filter return the Elasticsearch query clause of the filter f.
+func (b *ElasticBuilder) Filter(f Filter) (map[string]any, error) {
+	return b.clause(newStoreSchema(b.Schema, Option{}), f)
+}
+
+// elasticBool return the bool query of the clauses of the occurrence type occur (filter, should, must_not).
+func elasticBool(occur string, clauses []any) map[string]any {
+	query := map[string]any{}
+	if len(clauses) > 0 {
+		query[occur] = clauses
+	}
+	if occur == "should" {
+		query["minimum_should_match"] = 1
+	}
+	return map[string]any{"bool": query}
+}
+
+// elasticNot return the bool query of the documents not matching the clause c, in which the
+// fields exist : must_not matches the missing and null values, not matched in SQL.
+func elasticNot(c map[string]any, s storeSchema, fields ...string) map[string]any {
+	not := elasticBool("must_not", []any{c})
+	var exists []any
+	for _, field := range fields {
+		exists = append(exists, map[string]any{"exists": map[string]any{"field": s.field(field)}})
+	}
+	if len(exists) > 0 {
+		not["bool"].(map[string]any)["filter"] = exists
+	}
+	return not
+}
+
+// elasticFalse is a query clause matching no document.
+var elasticFalse = elasticBool("must_not", []any{map[string]any{"match_all": map[string]any{}}})
+
+// keyword return the name of the field, of its keyword sub-field for a string field.
+func (b *ElasticBuilder) keyword(s storeSchema, path string) string {
+	if b.Keyword != "" && s.isString(path) {
+		return s.field(path) + b.Keyword
+	}
+	return s.field(path)
+}
+
+func (b *ElasticBuilder) clause(s storeSchema, f Filter) (map[string]any, error) {
+	clauses := func(fs []Filter) ([]any, error) {
+		cs := make([]any, len(fs))
+		for i, sub := range fs {
+			c, err := b.clause(s, sub)
+			if err != nil {
+				return nil, err
+			}
+			cs[i] = c
+		}
+		return cs, nil
+	}
+
+	switch f := f.(type) {
+	case And:
+		cs, err := clauses(f)
+		if err != nil {
+			return nil, err
+		}
+		return elasticBool("filter", cs), nil
+	case Or:
+		if len(f) == 0 {
+			return elasticFalse, nil
+		}
+		cs, err := clauses(f)
+		if err != nil {
+			return nil, err
+		}
+		return elasticBool("should", cs), nil
+	case Not:
+		not, ok := negate(f.Filter).(Not)
+		if !ok {
+			return b.clause(s, negate(f.Filter))
+		}
+		c, err := b.clause(s, not.Filter)
+		if err != nil {
+			return nil, err
+		}
+		return elasticNot(c, s, s.nullable(not.Filter)...), nil
+	case Seek:
+		return b.clause(s, f.expand())
+	case Search:
+		var should []any
+		for _, field := range s.searchFields(f) {
+			should = append(should, elasticWildcard(b.keyword(s, field), "*"+wildcardQuote(f.Value)+"*"))
+		}
+		if len(should) == 0 {
+			return nil, errNoSearchFields
+		}
+		return elasticBool("should", should), nil
+	}
+
+	param, op, value, ok := filterOp(f)
+	if !ok {
+		return nil, fmt.Errorf("filter : unsupported filter type (%T)", f)
+	}
+	field := b.keyword(s, param)
+	var values []any
+	switch op {
+	case "like", "startswith", "regex", "isnull":
+		// the values are not values of the field
+	default:
+		var err error
+		if values, err = s.values(param, filterValues(f)); err != nil {
+			return nil, err
+		}
+	}
+	term := func(v any) map[string]any { return map[string]any{"term": map[string]any{field: v}} }
+	cond := func(query string, cond any) map[string]any { return map[string]any{query: map[string]any{field: cond}} }
+
+	switch op {
+	case "eq":
+		return term(values[0]), nil
+	case "ne":
+		return elasticNot(term(values[0]), s, param), nil
+	case "gt", "gte", "lt", "lte":
+		return cond("range", map[string]any{op: values[0]}), nil
+	case "between":
+		return cond("range", map[string]any{"gte": values[0], "lte": values[1]}), nil
+	case "in":
+		return map[string]any{"terms": map[string]any{field: values}}, nil
+	case "nin":
+		return elasticNot(map[string]any{"terms": map[string]any{field: values}}, s, param), nil
+	case "like":
+		return elasticWildcard(field, "*"+wildcardQuote(value)+"*"), nil
+	case "startswith":
+		return cond("prefix", map[string]any{"value": value, "case_insensitive": true}), nil
+	case "regex":
+		// Lucene regular expressions have no flags : a leading (?i) is the case_insensitive option
+		if pattern, ok := strings.CutPrefix(value, "(?i)"); ok {
+			return cond("regexp", map[string]any{"value": pattern, "case_insensitive": true}), nil
+		}
+		return cond("regexp", map[string]any{"value": value}), nil
+	case "isnull":
+		null, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, ErrParamType{param, err}
+		}
+		exists := map[string]any{"exists": map[string]any{"field": s.field(param)}}
+		if null {
+			return elasticBool("must_not", []any{exists}), nil
+		}
+		return exists, nil
+	}
+
+	// list operators
+	if s.isString(param) {
+		var cs []any
+		for _, v := range filterValues(f) {
+			cs = append(cs, cond("regexp", map[string]any{"value": "(.*,)?" + regexpQuote(v) + "(,.*)?"}))
+		}
+		if op == "overlaps" {
+			return elasticBool("should", cs), nil
+		}
+		return elasticBool("filter", cs), nil
+	}
+	switch op {
+	case "contain":
+		return term(values[0]), nil
+	case "containsAll":
+		cs := make([]any, len(values))
+		for i, v := range values {
+			cs[i] = term(v)
+		}
+		return elasticBool("filter", cs), nil
+	default:
+		return map[string]any{"terms": map[string]any{field: values}}, nil
+	}
+}
+
+// elasticWildcard return the case insensitive wildcard query of the pattern on the field.
+func elasticWildcard(field, pattern string) map[string]any {
+	return map[string]any{"wildcard": map[string]any{field: map[string]any{"value": pattern, "case_insensitive": true}}}
+}
+
+// wildcardQuote escape the wildcard characters of s : * ? and \.
+func wildcardQuote(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
+
+// regexpQuote escape the reserved characters of the Lucene regular expressions in s.
+func regexpQuote(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`.?+*|{}[]()"\#@&<>~`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,155 @@
+package alfred
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElasticFilter(t *testing.T) {
+	b := &ElasticBuilder{Schema: NewSchema(storeHero{}), Keyword: ".keyword"}
+	tests := map[string]struct {
+		filter Filter
+		want   string
+	}{
+		"eq":         {filter: EQ{"name", "Bruce"}, want: `{"term":{"name.keyword":"Bruce"}}`},
+		"ne":         {filter: NE{"age", "42"}, want: `{"bool":{"filter":[{"exists":{"field":"age"}}],"must_not":[{"term":{"age":42}}]}}`},
+		"gt":         {filter: GT{"score", "1.5"}, want: `{"range":{"score":{"gt":1.5}}}`},
+		"lte":        {filter: LTE{"birth", "1939-05-01"}, want: `{"range":{"birth":{"lte":"1939-05-01T00:00:00Z"}}}`},
+		"bool":       {filter: EQ{"alive", "true"}, want: `{"term":{"alive":true}}`},
+		"between":    {filter: Between{"age", "18", "30"}, want: `{"range":{"age":{"gte":18,"lte":30}}}`},
+		"in":         {filter: In{"age", []string{"1", "2"}}, want: `{"terms":{"age":[1,2]}}`},
+		"nin":        {filter: NotIn{"name", []string{"a"}}, want: `{"bool":{"filter":[{"exists":{"field":"name"}}],"must_not":[{"terms":{"name.keyword":["a"]}}]}}`},
+		"like":       {filter: Like{"name", "b*t"}, want: `{"wildcard":{"name.keyword":{"value":"*b\\*t*","case_insensitive":true}}}`},
+		"startswith": {filter: StartsWith{"name", "Bat"}, want: `{"prefix":{"name.keyword":{"value":"Bat","case_insensitive":true}}}`},
+		"regex":      {filter: Regex{"name", "B.*n"}, want: `{"regexp":{"name.keyword":{"value":"B.*n"}}}`},
+		"regex-i":    {filter: Regex{"name", "(?i)b.*N"}, want: `{"regexp":{"name.keyword":{"value":"b.*N","case_insensitive":true}}}`},
+		"isnull":     {filter: IsNull{"age", "true"}, want: `{"bool":{"must_not":[{"exists":{"field":"age"}}]}}`},
+		"not-null":   {filter: IsNull{"name", "false"}, want: `{"exists":{"field":"name"}}`},
+		"contain":    {filter: Contain{"tags", "rich"}, want: `{"term":{"tags":"rich"}}`},
+		"contains-all": {
+			filter: ContainsAll{"ranks", []string{"1", "2"}},
+			want:   `{"bool":{"filter":[{"term":{"ranks":1}},{"term":{"ranks":2}}]}}`,
+		},
+		"overlaps": {filter: Overlaps{"tags", []string{"a", "b"}}, want: `{"terms":{"tags":["a","b"]}}`},
+		"list-string": {
+			filter: Overlaps{"powers", []string{"x.ray"}},
+			want:   `{"bool":{"should":[{"regexp":{"powers.keyword":{"value":"(.*,)?x\\.ray(,.*)?"}}}],"minimum_should_match":1}}`,
+		},
+		"column": {filter: EQ{"address.city", "Gotham"}, want: `{"term":{"address.town.keyword":"Gotham"}}`},
+		"and": {
+			filter: And{EQ{"age", "40"}, Not{EQ{"alive", "false"}}},
+			want:   `{"bool":{"filter":[{"term":{"age":40}},{"bool":{"filter":[{"exists":{"field":"alive"}}],"must_not":[{"term":{"alive":false}}]}}]}}`,
+		},
+		"not-group": {
+			filter: Not{Or{EQ{"age", "40"}, IsNull{"name", "true"}}},
+			want: `{"bool":{"filter":[
+				{"bool":{"filter":[{"exists":{"field":"age"}}],"must_not":[{"term":{"age":40}}]}},
+				{"bool":{"must_not":[{"bool":{"must_not":[{"exists":{"field":"name"}}]}}]}}
+			]}}`,
+		},
+		"or": {
+			filter: Or{EQ{"age", "40"}, EQ{"age", "41"}},
+			want:   `{"bool":{"should":[{"term":{"age":40}},{"term":{"age":41}}],"minimum_should_match":1}}`,
+		},
+		"or-none": {filter: Or{}, want: `{"bool":{"must_not":[{"match_all":{}}]}}`},
+		"search": {
+			filter: Search{Value: "bat"},
+			want:   `{"bool":{"should":[{"wildcard":{"name.keyword":{"value":"*bat*","case_insensitive":true}}}],"minimum_should_match":1}}`,
+		},
+		"seek": {
+			filter: Seek{Keys: []SortKey{{"age", true}}, Values: []string{"40"}},
+			want: `{"bool":{"should":[{"bool":{"should":[
+				{"range":{"age":{"lt":40}}},
+				{"bool":{"must_not":[{"exists":{"field":"age"}}]}}
+			],"minimum_should_match":1}}],"minimum_should_match":1}}`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			clause, err := b.Filter(tt.filter)
+			require.NoError(t, err)
+			got, err := json.Marshal(clause)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+
+	_, err := (&ElasticBuilder{}).Filter(Search{Value: "bat"})
+	assert.ErrorIs(t, err, errNoSearchFields)
+
+	_, err = b.Filter(EQ{"age", "old"})
+	assert.EqualError(t, err, `filter: field 'age' : strconv.ParseInt: parsing "old": invalid syntax`)
+	_, err = b.Filter(customFilter{})
+	assert.EqualError(t, err, "filter : unsupported filter type (alfred.customFilter)")
+}
+
+func TestBuildElasticQuery(t *testing.T) {
+	tests := map[string]struct {
+		opt  Option
+		want string
+	}{
+		"empty": {opt: Option{}, want: `{"query":{"bool":{}}}`},
+		"full": {
+			opt: Option{
+				Limit:   10,
+				Offset:  20,
+				SortBy:  "-age,name",
+				Fields:  []string{"name"},
+				Filters: Filters{GT{"age", "18"}, Like{"name", "bat"}},
+			},
+			want: `{
+				"query": {"bool": {"filter": [
+					{"range": {"age": {"gt": "18"}}},
+					{"wildcard": {"name": {"value": "*bat*", "case_insensitive": true}}}
+				]}},
+				"sort": [
+					{"age": {"order": "desc", "missing": "_last"}},
+					{"name": {"order": "asc", "missing": "_first"}}
+				],
+				"from": 20,
+				"size": 10,
+				"_source": ["name"]
+			}`,
+		},
+		"nulls": {
+			opt:  Option{SortBy: "-age", Nulls: "first"},
+			want: `{"query":{"bool":{}},"sort":[{"age":{"order":"desc","missing":"_first"}}]}`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			body, err := BuildElasticQuery(tt.opt)
+			require.NoError(t, err)
+			got, err := json.Marshal(body)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestElasticQuerySchema(t *testing.T) {
+	opt := NewSchema(storeHero{}).ParseURLValues(map[string][]string{
+		"filter[team][like]": {"jl"},
+		"filter[team][in]":   {"jla,jsa"},
+		"filter[birth][lt]":  {"today"},
+		"sortBy":             {"address.city,-team"},
+		"fields":             {"name,address.city"},
+	})
+	opt.Now = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	body, err := (&ElasticBuilder{Keyword: ".raw"}).Query(opt)
+	require.NoError(t, err)
+	got, err := json.Marshal(body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"query": {"bool": {"filter": [
+			{"range": {"birth": {"lt": "2024-06-01T00:00:00Z"}}},
+			{"terms": {"team_name.raw": ["jla", "jsa"]}}
+		]}},
+		"sort": [{"address.town.raw": {"order": "asc", "missing": "_first"}}],
+		"_source": ["name", "address.town"]
+	}`, string(got))
+}
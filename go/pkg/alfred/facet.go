@@ -0,0 +1,128 @@
+package alfred
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// A Facet is a distinct value of a field, and the number of items with this value.
+type Facet struct {
+	// Value formatted as a filter value, empty if Null.
+	Value string `json:"value"`
+	Null  bool   `json:"null,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Facets return the distinct values of the field of the items kept by the filters of opt, and
+// their number of items. Sorting, pagination and cursor are ignored. Facets are sorted by
+// decreasing count, then by value, null first. It returns an error if field is not a field of
+// T, or if it cannot be sorted.
+func Facets[T any](items []T, opt Option, field string) ([]Facet, error) {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("filter : not a struct (%v)", reflect.TypeFor[T]())
+	}
+	index, sf, ok := resolveField(t, field)
+	if !ok {
+		return nil, fmt.Errorf("filter : unknown field (%v)", field)
+	}
+	info := scalarOf(sf.Type)
+	if info.kind == kindUnsupported || info.kind == kindList {
+		return nil, fmt.Errorf("filter : unsuported field type (%v as %v)", field, sf.Type.String())
+	}
+
+	now := opt.now()
+	kept, err := filterItems(items, resolveTimes(t, opt.Filters, now), now)
+	if err != nil {
+		return nil, err
+	}
+
+	type facetValue struct {
+		facet Facet
+		value reflect.Value
+	}
+	var facets []*facetValue
+	byValue := map[Facet]*facetValue{}
+	for _, item := range kept {
+		fv, ok := fieldByIndex(reflect.ValueOf(item), index)
+		if ok {
+			fv, ok = info.scalar(fv)
+		}
+		key := Facet{Null: !ok}
+		if ok {
+			if key.Value, err = formatValue(fv); err != nil {
+				return nil, err
+			}
+		}
+		f, found := byValue[key]
+		if !found {
+			f = &facetValue{facet: key, value: fv}
+			byValue[key] = f
+			facets = append(facets, f)
+		}
+		f.facet.Count++
+	}
+
+	compare := comparator(sf.Type)
+	slices.SortFunc(facets, func(a, b *facetValue) int {
+		if c := cmp.Compare(b.facet.Count, a.facet.Count); c != 0 {
+			return c
+		}
+		if a.facet.Null || b.facet.Null {
+			return cmp.Compare(btoi(!a.facet.Null), btoi(!b.facet.Null))
+		}
+		return compare(a.value, b.value)
+	})
+	result := make([]Facet, len(facets))
+	for i, f := range facets {
+		result[i] = f.facet
+	}
+	return result, nil
+}
+
+// BuildFacetQuery return the query counting the rows of query kept by the filters of opt per
+// distinct value of the field, and its arguments, in the SQL dialect d. See SQLBuilder.FacetQuery.
+func BuildFacetQuery(d Dialect, query string, opt Option, field string) (string, []any) {
+	b := &SQLBuilder{Dialect: d}
+	return b.FacetQuery(query, opt, field), b.Args()
+}
+
+// FacetQuery return the query counting the rows of query kept by the filters of opt per distinct
+// value of the field, with the same filtered subquery as Query. Sorting, pagination and cursor are
+// ignored. The rows are the values and their count, sorted by decreasing count then by value, null
+// first as Facets :
+// SELECT <field> AS value, count(*) AS count FROM (<query>) AS query WHERE <filter1> ILIKE $1 AND ...
+// GROUP BY <field> ORDER BY count(*) DESC, <field> ASC NULLS FIRST
+func (b *SQLBuilder) FacetQuery(query string, opt Option, field string) string {
+	defer b.withSchema(opt)()
+	col := b.Column(field)
+	from := fmt.Sprintf("(%s) AS query", query)
+	if where := b.where(b.resolveTimes(opt.Filters, opt)); where != "" {
+		from += " " + where
+	}
+	return fmt.Sprintf("SELECT %s AS value, count(*) AS count FROM %s GROUP BY %s ORDER BY count(*) DESC, %s",
+		col, from, col, b.dialect().OrderBy(col, false, nullsFirst))
+}
+
+// ScanFacets return the facets of the rows of a facet query, see SQLBuilder.FacetQuery.
+// Values are formatted by the database driver. The rows are closed.
+func ScanFacets(rows *sql.Rows) ([]Facet, error) {
+	defer rows.Close()
+	var facets []Facet
+	for rows.Next() {
+		var value sql.NullString
+		var f Facet
+		if err := rows.Scan(&value, &f.Count); err != nil {
+			return nil, err
+		}
+		f.Value, f.Null = value.String, !value.Valid
+		facets = append(facets, f)
+	}
+	return facets, rows.Err()
+}
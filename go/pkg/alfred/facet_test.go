@@ -0,0 +1,85 @@
+package alfred
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFacets(t *testing.T) {
+	tests := map[string]struct {
+		opt      Option
+		field    string
+		expected []Facet
+	}{
+		"team": {
+			field:    "team",
+			expected: []Facet{{Value: "jla", Count: 4}, {Value: "avengers", Count: 2}},
+		},
+		"filtered": {
+			opt:      Option{Filters: Filters{GT{"age", "36"}}, Limit: 1, Offset: 1, SortBy: "name"},
+			field:    "team",
+			expected: []Facet{{Value: "avengers", Count: 2}, {Value: "jla", Count: 2}},
+		},
+		"int": {
+			opt:      Option{Filters: Filters{EQ{"team", "jla"}}},
+			field:    "age",
+			expected: []Facet{{Value: "28", Count: 1}, {Value: "35", Count: 1}, {Value: "39", Count: 1}, {Value: "5000", Count: 1}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			facets, err := Facets(sqliteHeroes, tt.opt, tt.field)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, facets)
+		})
+	}
+}
+
+func TestFacetsNull(t *testing.T) {
+	facets, err := Facets(valueHeroes, Option{}, "city")
+	require.NoError(t, err)
+	assert.Equal(t, []Facet{{Null: true, Count: 1}, {Value: "Gotham", Count: 1}, {Value: "Themyscira", Count: 1}}, facets)
+
+	heroes := []*valueHero{&valueHeroes[0], nil, &valueHeroes[2]}
+	facets, err = Facets(heroes, Option{}, "status")
+	require.NoError(t, err)
+	assert.Equal(t, []Facet{{Value: "active", Count: 2}, {Null: true, Count: 1}}, facets)
+}
+
+func TestFacetsErrors(t *testing.T) {
+	_, err := Facets(sqliteHeroes, Option{}, "power")
+	assert.EqualError(t, err, "filter : unknown field (power)")
+	_, err = Facets(listHeroes, Option{}, "powers")
+	assert.EqualError(t, err, "filter : unsuported field type (powers as []string)")
+	_, err = Facets([]int{1}, Option{}, "value")
+	assert.EqualError(t, err, "filter : not a struct (int)")
+	_, err = Facets(sqliteHeroes, Option{Filters: Filters{GT{"name", "a"}}}, "team")
+	assert.Error(t, err)
+}
+
+func TestFacetQuery(t *testing.T) {
+	query, args := BuildFacetQuery(Postgres{}, "SELECT * FROM heroes", Option{Filters: Filters{GT{"age", "36"}}, Limit: 10}, "team")
+	assert.Equal(t, `SELECT "team" AS value, count(*) AS count FROM (SELECT * FROM heroes) AS query WHERE "age" > $1 GROUP BY "team" ORDER BY count(*) DESC, "team" ASC NULLS FIRST`, query)
+	assert.Equal(t, []any{"36"}, args)
+
+	query, args = BuildFacetQuery(MySQL{}, "SELECT * FROM heroes", Option{}, "address.city")
+	assert.Equal(t, "SELECT `address`.`city` AS value, count(*) AS count FROM (SELECT * FROM heroes) AS query GROUP BY `address`.`city` ORDER BY count(*) DESC, `address`.`city` IS NULL DESC, `address`.`city` ASC", query)
+	assert.Empty(t, args)
+}
+
+func TestSQLiteFacets(t *testing.T) {
+	db := openSQLite(t)
+	for _, opt := range []Option{{}, {Filters: Filters{GT{"age", "36"}}}, {Filters: Filters{Like{"tags", "flight"}}}} {
+		query, args := BuildFacetQuery(SQLite{}, "SELECT * FROM heroes", opt, "team")
+		rows, err := db.Query(query, args...)
+		require.NoError(t, err)
+		facets, err := ScanFacets(rows)
+		require.NoError(t, err)
+
+		expected, err := Facets(sqliteHeroes, opt, "team")
+		require.NoError(t, err)
+		assert.Equal(t, expected, facets)
+	}
+}
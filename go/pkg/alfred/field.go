@@ -0,0 +1,271 @@
+package alfred
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// tagName return the name of the field in its 'filter' tag.
+func tagName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("filter"), ",")
+	return name
+}
+
+// fieldTag is the 'filter' tag of a field, its name followed by options :
+// 'name,search,sortable,ops=eq|like,column=hero_name'.
+type fieldTag struct {
+	name string
+	// search is true if the field is searched by a Search filter without fields.
+	search bool
+	// sortable is true if the field is declared sortable, see sortable.
+	sortable bool
+	// ops are the operators allowed on the field, all of them if nil.
+	ops []string
+	// column is the SQL column of the field, the name of the field in its path if empty.
+	column string
+}
+
+// parseTag return the 'filter' tag of the field. Unknown options are ignored.
+func parseTag(sf reflect.StructField) fieldTag {
+	name, options, _ := strings.Cut(sf.Tag.Get("filter"), ",")
+	tag := fieldTag{name: name}
+	for _, option := range strings.Split(options, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		switch key {
+		case "search":
+			tag.search = true
+		case "sortable":
+			tag.sortable = true
+		case "ops":
+			// 'ops=' allows no operator
+			tag.ops = strings.Split(value, "|")
+		case "column":
+			tag.column = value
+		}
+	}
+	return tag
+}
+
+// allows return true if the operator op is allowed on the field, case insensitive.
+func (tag fieldTag) allows(op string) bool {
+	return tag.ops == nil || slices.ContainsFunc(tag.ops, func(s string) bool { return strings.EqualFold(s, op) })
+}
+
+// errNotAllowed is the error of a filter whose operator is not allowed on its field.
+func errNotAllowed(param, op string) error {
+	return fmt.Errorf("filter : operator not allowed (%v %v)", param, op)
+}
+
+// sortableTypes caches the result of declaresSortable.
+var sortableTypes sync.Map // reflect.Type -> bool
+
+// declaresSortable return true if a field of the struct type t, or of its nested and embedded
+// structs, is tagged sortable.
+func declaresSortable(t reflect.Type) bool {
+	if r, ok := sortableTypes.Load(t); ok {
+		return r.(bool)
+	}
+	visited := map[reflect.Type]bool{}
+	var declares func(t reflect.Type) bool
+	declares = func(t reflect.Type) bool {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || visited[t] {
+			return false
+		}
+		visited[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if (sf.IsExported() && parseTag(sf).sortable) || declares(sf.Type) {
+				return true
+			}
+		}
+		return false
+	}
+	r := declares(t)
+	sortableTypes.Store(t, r)
+	return r
+}
+
+// sortable return true if the field at path of the struct type t can be sorted : if it is tagged
+// sortable, or if no field of t is (see declaresSortable). Unknown fields are not sortable.
+func sortable(t reflect.Type, path string) bool {
+	_, sf, ok := resolveField(t, path)
+	return ok && (!declaresSortable(t) || parseTag(sf).sortable)
+}
+
+// columnPath return the names of the SQL columns on the path of the field at path of the struct
+// type t : the 'column' option of the tag of each field, or its name in path.
+func columnPath(t reflect.Type, path string) []string {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			break
+		}
+		sf, ok := findField(t, name)
+		if !ok {
+			break
+		}
+		if column := parseTag(sf).column; column != "" {
+			names[i] = column
+		}
+		t = sf.Type
+	}
+	return names
+}
+
+type fieldKey struct {
+	typ  reflect.Type
+	path string
+}
+
+type resolvedField struct {
+	index []int
+	sf    reflect.StructField
+}
+
+// maxResolvedFields bounds the number of fields cached by resolveField : paths come from the
+// requests, and the paths of types referencing themselves are not bounded.
+const maxResolvedFields = 4096
+
+var (
+	// resolvedFields caches the fields found by resolveField.
+	resolvedFields     sync.Map // fieldKey -> resolvedField
+	resolvedFieldCount atomic.Int64
+)
+
+// resolveField return the index sequence of the field at path in the struct type t.
+// Path is a dot separated list of field names or 'filter' tags ('address.city').
+// Pointers to structs are dereferenced and fields of embedded structs are promoted.
+// Found fields are cached per type and path, up to maxResolvedFields, the returned
+// index must not be modified. Unknown fields are not cached.
+func resolveField(t reflect.Type, path string) ([]int, reflect.StructField, bool) {
+	key := fieldKey{typ: t, path: path}
+	if r, ok := resolvedFields.Load(key); ok {
+		r := r.(resolvedField)
+		return r.index, r.sf, true
+	}
+	index, sf, ok := resolveFieldPath(t, path)
+	if ok && resolvedFieldCount.Load() < maxResolvedFields {
+		if _, loaded := resolvedFields.LoadOrStore(key, resolvedField{index: index, sf: sf}); !loaded {
+			resolvedFieldCount.Add(1)
+		}
+	}
+	return index, sf, ok
+}
+
+func resolveFieldPath(t reflect.Type, path string) ([]int, reflect.StructField, bool) {
+	var index []int
+	var sf reflect.StructField
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, sf, false
+		}
+		var ok bool
+		sf, ok = findField(t, name)
+		if !ok {
+			return nil, sf, false
+		}
+		index = append(index, sf.Index...)
+		t = sf.Type
+	}
+	return index, sf, true
+}
+
+// findField return the exported field of the struct type t known as name, looking
+// in embedded structs breadth first. The index of the returned field is relative to t.
+func findField(t reflect.Type, name string) (reflect.StructField, bool) {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	visited := map[reflect.Type]bool{}
+	current := []embedded{{typ: t}}
+	for len(current) > 0 {
+		var next []embedded
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				index := append(slices.Clone(e.index), i)
+				if sf.Anonymous {
+					et := sf.Type
+					if et.Kind() == reflect.Pointer {
+						et = et.Elem()
+					}
+					if et.Kind() == reflect.Struct {
+						next = append(next, embedded{typ: et, index: index})
+					}
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				if sf.Name == name || tagName(sf) == name {
+					sf.Index = index
+					return sf, true
+				}
+			}
+		}
+		current = next
+	}
+	return reflect.StructField{}, false
+}
+
+// fieldByIndex return the field of the struct v at index, dereferencing pointers
+// to structs on the path. It returns false if a nil pointer is on the path.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+// lookupField return the compared value of the field at path of the struct v, or of the struct pointed
+// by v (see scalarOf). found is false if there is no such field, and the value is invalid if it is null
+// or if a nil pointer is on the path. Fields of documents are always found, missing fields being null
+// (see lookupDocument). With an operator, it returns an error if op is not allowed on the field of a
+// struct (see fieldTag).
+func lookupField(v any, path, op string) (fv reflect.Value, found bool, err error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.IsValid() && isDocument(rv.Type()) {
+		return lookupDocument(rv, path), true, nil
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, false, fmt.Errorf("filter : not a struct (%v)", reflect.TypeOf(v))
+	}
+	index, sf, ok := resolveField(rv.Type(), path)
+	if !ok {
+		return reflect.Value{}, false, nil
+	}
+	if op != "" && !parseTag(sf).allows(op) {
+		return reflect.Value{}, false, errNotAllowed(path, op)
+	}
+	fv, ok = fieldByIndex(rv, index)
+	if ok {
+		fv, _ = scalar(fv)
+	}
+	return fv, true, nil
+}
@@ -0,0 +1,283 @@
+package alfred
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fieldAddress struct {
+	City    string `filter:"city"`
+	Country *fieldCountry
+}
+
+type fieldCountry struct {
+	Code string `filter:"code"`
+}
+
+type fieldBase struct {
+	ID int `filter:"id"`
+}
+
+type fieldHero struct {
+	fieldBase
+	Name    string       `filter:"name,search"`
+	Address fieldAddress `filter:"address"`
+	Owner   *fieldHero   `filter:"owner"`
+}
+
+func TestKeepNested(t *testing.T) {
+	bruce := fieldHero{
+		fieldBase: fieldBase{ID: 1},
+		Name:      "Bruce",
+		Address:   fieldAddress{City: "Gotham", Country: &fieldCountry{Code: "US"}},
+	}
+	dick := fieldHero{
+		fieldBase: fieldBase{ID: 2},
+		Name:      "Dick",
+		Address:   fieldAddress{City: "Bludhaven"},
+		Owner:     &bruce,
+	}
+	tests := map[string]struct {
+		data   any
+		filter Filter
+		want   bool
+	}{
+		"tag":              {data: bruce, filter: EQ{"name", "Bruce"}, want: true},
+		"nested":           {data: bruce, filter: EQ{"Address.city", "Gotham"}, want: true},
+		"nested-drop":      {data: dick, filter: EQ{"Address.city", "Gotham"}, want: false},
+		"nested-pointer":   {data: bruce, filter: EQ{"Address.Country.code", "US"}, want: true},
+		"nil-pointer":      {data: dick, filter: EQ{"Address.Country.code", "US"}, want: false},
+		"nil-pointer-not":  {data: dick, filter: Not{EQ{"Address.Country.code", "US"}}, want: false},
+		"pointer-struct":   {data: dick, filter: Like{"owner.name", "bru"}, want: true},
+		"pointer-value":    {data: &dick, filter: Like{"owner.Address.city", "goth"}, want: true},
+		"embedded":         {data: bruce, filter: EQ{"id", "1"}, want: true},
+		"embedded-drop":    {data: dick, filter: EQ{"ID", "1"}, want: false},
+		"embedded-nested":  {data: dick, filter: GT{"owner.id", "0"}, want: true},
+		"unknown":          {data: bruce, filter: EQ{"Address.unknown", "x"}, want: true},
+		"unknown-not-path": {data: bruce, filter: EQ{"name.first", "x"}, want: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := tt.filter.Keep(tt.data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, actual)
+		})
+	}
+}
+
+func TestKeepNotAStruct(t *testing.T) {
+	_, err := EQ{"name", "bruce"}.Keep("bruce")
+	assert.EqualError(t, err, "filter : not a struct (string)")
+}
+
+func TestResolveFieldCache(t *testing.T) {
+	typ := reflect.TypeOf(fieldHero{})
+	_, _, ok := resolveField(typ, "unknown")
+	assert.False(t, ok)
+	_, cached := resolvedFields.Load(fieldKey{typ: typ, path: "unknown"})
+	assert.False(t, cached, "unknown fields are not cached")
+
+	// paths of self referencing types are not bounded
+	for i := 0; i < maxResolvedFields+10; i++ {
+		path := "name"
+		for bit := 0; bit < 13; bit++ {
+			if i&(1<<bit) != 0 {
+				path = "Owner." + path
+			} else {
+				path = "owner." + path
+			}
+		}
+		_, _, ok := resolveField(typ, path)
+		require.True(t, ok)
+	}
+	assert.LessOrEqual(t, resolvedFieldCount.Load(), int64(maxResolvedFields))
+}
+
+func TestSortNested(t *testing.T) {
+	bruce := fieldHero{fieldBase: fieldBase{ID: 3}, Name: "Bruce", Address: fieldAddress{City: "Gotham"}}
+	clark := fieldHero{fieldBase: fieldBase{ID: 1}, Name: "Clark", Address: fieldAddress{City: "Metropolis"}, Owner: &bruce}
+	diana := fieldHero{fieldBase: fieldBase{ID: 2}, Name: "Diana", Address: fieldAddress{City: "Themyscira"}, Owner: &clark}
+	tests := map[string]struct {
+		opt  Option
+		want []fieldHero
+	}{
+		"tag":           {opt: Option{SortBy: "-name"}, want: []fieldHero{diana, clark, bruce}},
+		"nested":        {opt: Option{SortBy: "Address.city", Order: orderDESC}, want: []fieldHero{diana, clark, bruce}},
+		"embedded":      {opt: Option{SortBy: "id"}, want: []fieldHero{clark, diana, bruce}},
+		"nil-pointer":   {opt: Option{SortBy: "owner.name"}, want: []fieldHero{bruce, clark, diana}},
+		"nil-pointer-d": {opt: Option{SortBy: "-owner.name"}, want: []fieldHero{diana, clark, bruce}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			arr := []fieldHero{bruce, diana, clark}
+			tt.opt.Sort(arr)
+			assert.Equal(t, tt.want, arr)
+
+			ptrs := []*fieldHero{&arr[2], &arr[0], &arr[1]}
+			tt.opt.Sort(ptrs)
+			for i := range ptrs {
+				assert.Equal(t, tt.want[i], *ptrs[i])
+			}
+		})
+	}
+}
+
+func TestColumn(t *testing.T) {
+	tests := map[string]struct {
+		builder *SQLBuilder
+		filter  Filter
+		want    string
+	}{
+		"field":     {builder: &SQLBuilder{}, filter: EQ{"city", "Gotham"}, want: `"city" = $1`},
+		"composite": {builder: &SQLBuilder{}, filter: EQ{"address.city", "Gotham"}, want: `("address")."city" = $1`},
+		"composite-deep": {
+			builder: &SQLBuilder{},
+			filter:  EQ{"address.country.code", "US"},
+			want:    `(("address")."country")."code" = $1`,
+		},
+		"json": {builder: &SQLBuilder{JSONPath: true}, filter: EQ{"address.city", "Gotham"}, want: `"address"->>'city' = $1`},
+		"json-deep": {
+			builder: &SQLBuilder{JSONPath: true},
+			filter:  EQ{"address.country.code", "US"},
+			want:    `"address"->'country'->>'code' = $1`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.BuildSQL(tt.builder))
+		})
+	}
+}
+
+func TestParseNestedFromURLValues(t *testing.T) {
+	opt := ParseURLValues(map[string][]string{"filter[address.city][eq]": {"Paris"}, "sortBy": {"owner.name"}})
+	assert.Equal(t, Filters{EQ{"address.city", "Paris"}}, opt.Filters)
+	assert.Equal(t, []SortKey{{"owner.name", false}}, opt.SortKeys())
+	assert.NoError(t, opt.Validate(NewSchema(fieldHero{})))
+}
+
+type tagAddress struct {
+	City string `filter:"city,sortable,column=town"`
+	Zip  string `filter:"zip"`
+}
+
+type tagHero struct {
+	Name    string     `filter:"name,ops=eq|like,sortable,column=hero_name"`
+	Age     int        `filter:"age,ops=gt|LT|between, sortable"`
+	Team    string     `filter:"team,ops="`
+	Secret  string     `filter:"secret"`
+	Address tagAddress `filter:"address,column=addr"`
+}
+
+func TestParseTag(t *testing.T) {
+	tests := map[string]struct {
+		tag  string
+		want fieldTag
+	}{
+		"empty":   {tag: ``, want: fieldTag{}},
+		"name":    {tag: `filter:"name"`, want: fieldTag{name: "name"}},
+		"search":  {tag: `filter:"name,search"`, want: fieldTag{name: "name", search: true}},
+		"ops":     {tag: `filter:",ops=eq|like"`, want: fieldTag{ops: []string{"eq", "like"}}},
+		"no-ops":  {tag: `filter:"name,ops="`, want: fieldTag{name: "name", ops: []string{""}}},
+		"unknown": {tag: `filter:"name,index"`, want: fieldTag{name: "name"}},
+		"all": {
+			tag:  `filter:"name,ops=eq|like,sortable,column=hero_name,search"`,
+			want: fieldTag{name: "name", search: true, sortable: true, ops: []string{"eq", "like"}, column: "hero_name"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseTag(reflect.StructField{Tag: reflect.StructTag(tt.tag)}))
+		})
+	}
+}
+
+func TestKeepNotAllowed(t *testing.T) {
+	hero := tagHero{Name: "Bruce", Age: 42, Team: "jla", Secret: "batman"}
+	tests := map[string]struct {
+		filter Filter
+		want   bool
+		err    string
+	}{
+		"allowed":        {filter: EQ{"name", "Bruce"}, want: true},
+		"allowed-case":   {filter: LT{"age", "50"}, want: true},
+		"allowed-all":    {filter: Regex{"secret", "^bat"}, want: true},
+		"not-allowed":    {filter: GTE{"age", "18"}, err: "filter : operator not allowed (age gte)"},
+		"none-allowed":   {filter: EQ{"team", "jla"}, err: "filter : operator not allowed (team eq)"},
+		"in-group":       {filter: Or{EQ{"name", "Clark"}, Not{StartsWith{"name", "B"}}}, err: "filter : operator not allowed (name startswith)"},
+		"list":           {filter: Contain{"team", "jla"}, err: "filter : operator not allowed (team contain)"},
+		"unknown-field":  {filter: EQ{"power", "flight"}, want: true},
+		"nested-allowed": {filter: IsNull{"address.zip", "false"}, want: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := Filters{tt.filter}.Keep(hero)
+			compiled, cerr := Filters{tt.filter}.Compile(hero)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				assert.EqualError(t, cerr, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, cerr)
+			assert.Equal(t, tt.want, actual)
+			actual, err = compiled.Keep(hero)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, actual)
+		})
+	}
+
+	_, _, err := Apply([]tagHero{hero}, Option{Filters: Filters{EQ{"team", "jla"}}})
+	assert.EqualError(t, err, "filter : operator not allowed (team eq)")
+}
+
+func TestSortSortable(t *testing.T) {
+	bruce := tagHero{Name: "Bruce", Age: 42, Team: "jla", Address: tagAddress{City: "Gotham"}}
+	clark := tagHero{Name: "Clark", Age: 35, Team: "jla", Address: tagAddress{City: "Metropolis"}}
+	diana := tagHero{Name: "Diana", Age: 35, Team: "amazons", Address: tagAddress{City: "Themyscira"}}
+	tests := map[string]struct {
+		sortBy string
+		want   []tagHero
+	}{
+		"sortable":         {sortBy: "-name", want: []tagHero{diana, clark, bruce}},
+		"nested":           {sortBy: "-address.city", want: []tagHero{diana, clark, bruce}},
+		"not-sortable":     {sortBy: "team", want: []tagHero{clark, bruce, diana}},
+		"ignored-key":      {sortBy: "secret,age,-name", want: []tagHero{diana, clark, bruce}},
+		"nested-ignored":   {sortBy: "address.zip", want: []tagHero{clark, bruce, diana}},
+		"without-sortable": {sortBy: "", want: []tagHero{clark, bruce, diana}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			arr := []tagHero{clark, bruce, diana}
+			Option{SortBy: tt.sortBy}.Sort(arr)
+			assert.Equal(t, tt.want, arr)
+		})
+	}
+
+	// without sortable fields, all the fields are sortable
+	assert.True(t, sortable(reflect.TypeFor[fieldHero](), "Address.city"))
+	assert.False(t, sortable(reflect.TypeFor[tagHero](), "secret"))
+	assert.True(t, sortable(reflect.TypeFor[tagHero](), "address.city"))
+}
+
+func TestColumnTag(t *testing.T) {
+	tests := map[string]struct {
+		filter Filter
+		want   string
+	}{
+		"column":       {filter: EQ{"name", "Bruce"}, want: `"hero_name" = $1`},
+		"field-name":   {filter: EQ{"Name", "Bruce"}, want: `"hero_name" = $1`},
+		"no-column":    {filter: EQ{"secret", "x"}, want: `"secret" = $1`},
+		"nested":       {filter: EQ{"address.city", "Gotham"}, want: `("addr")."town" = $1`},
+		"nested-field": {filter: EQ{"address.zip", "1"}, want: `("addr")."zip" = $1`},
+		"unknown":      {filter: EQ{"address.country.code", "US"}, want: `(("addr")."country")."code" = $1`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.BuildSQL(&SQLBuilder{Schema: NewSchema(tagHero{})}))
+		})
+	}
+}
@@ -1,15 +1,14 @@
 package alfred
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
-	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/lib/pq"
 )
 
 // Option are filtering, sorting and pagination parameters.
@@ -17,69 +16,196 @@ type Option struct {
 	// pagination
 	Limit  int
 	Offset int
+	// Cursor are the values of the sort keys of the last item of the previous page,
+	// for keyset pagination.
+	Cursor []string
 
 	// sorting
 	Order  string
 	SortBy string
+	// Collation is the BCP 47 language tag of the order of strings ('fr'), see Option.Sort.
+	Collation string
+	// Nulls is the position of null values, 'first' or 'last', lower than any other value if empty.
+	Nulls string
 
 	// filters
 	Filters Filters
+
+	// Fields are the fields to return, all fields if empty (sparse fieldsets).
+	Fields []string
+
+	// Now is the instant relative time values ('now-7d') are resolved against, the current time if zero.
+	Now time.Time
+
+	// invalid parameters dropped by ParseURLValues, reported by Validate
+	errs ErrValidation
+	// schema of an option parsed by Schema.ParseURLValues, the schema of its SQL queries
+	schema *Schema
+	// parser of an option parsed by MiddlewareWith, the syntax of its URLs
+	parser Parser
 }
 
-var paramFilter = regexp.MustCompile(`([a-zA-Z1-9_\-]+)\[([a-zA-Z1-9_\-]+)\]\[([a-zA-Z1-9_\-]+)\]`)
+var (
+	paramFilter  = regexp.MustCompile(`^filter(\[[a-zA-Z0-9_.\-]+\])+$`)
+	paramSegment = regexp.MustCompile(`\[([a-zA-Z0-9_.\-]+)\]`)
+	paramName    = regexp.MustCompile(`^[a-zA-Z0-9_.\-]+$`)
+)
 
-// ParseURLValues parse filters from url values. The format is '?filter[<>][<type>]=<value>'.
-// Possible type are : like, eq, gt, gte, lt, lte.
+// ParseURLValues parse filters from url values. The format is '?filter[<field>][<type>]=<value>'.
+// The field is a field name or 'filter' tag, or a dot separated path to a field of a nested struct ('address.city').
+// Possible type are : like, eq, ne, gt, gte, lt, lte, contain, containsAll, overlaps, in, nin, between, isnull,
+// startswith, regex. Values of in, nin, containsAll and overlaps are comma separated ('?filter[status][in]=a,b'),
+// as well as the two bounds of between
+// ('?filter[age][between]=18,30'). The value of isnull is a boolean ('?filter[deletedAt][isnull]=true').
+//
+// Filters are combined with AND. A filter can be negated with a 'not' segment
+// ('?filter[not][status][eq]=archived'), and filters sharing an 'or' or 'and'
+// group label are combined together ('?filter[or][g1][name][like]=bat&filter[or][g1][name][like]=super').
+// Groups can be nested and negated the same way ('?filter[not][or][g1][and][g2][age][gt]=18').
+//
+// Invalid parameters are dropped, and reported by Option.Validate. See Schema.ParseURLValues to also
+// drop the filters and sort keys not allowed by the tags of the fields of a struct, and Limits to bound
+// the pagination and the number of filters.
+//
+// Sorting is a comma separated list of fields, prefixed with '-' for descending order ('?sortBy=team,-createdAt'),
+// strings being ordered by a collation ('?collation=fr') and null values first or last ('?nulls=last').
+//
+// Free text search on the searchable fields ('filter:"name,search"') is a Search filter ('?q=batman').
+//
+// Keyset pagination uses the cursor token returned by Option.NextCursor ('?sortBy=name,id&limit=10&cursor=<token>').
+//
+// The fields to return are a comma separated list of fields ('?fields=id,name'), see Option.Project.
 func ParseURLValues(values url.Values) Option {
 	var err error
 	f := Option{}
 
-	f.Limit, err = strconv.Atoi(values.Get("limit"))
+	limitS := values.Get("limit")
+	f.Limit, err = strconv.Atoi(limitS)
 	if err != nil {
 		f.Limit = 0
+		if limitS != "" {
+			f.errs = append(f.errs, ErrInvalidParam{Param: "limit", Value: limitS, Reason: "invalid integer"})
+		}
 	}
 	offsetS := values.Get("offset")
 	f.Offset, err = strconv.Atoi(offsetS)
 	if err != nil {
 		f.Offset = 0
+		if offsetS != "" {
+			f.errs = append(f.errs, ErrInvalidParam{Param: "offset", Value: offsetS, Reason: "invalid integer"})
+		}
 	}
 
 	f.SortBy = values.Get("sortBy")
 	f.Order = values.Get("orderBy")
+	f.Collation = values.Get("collation")
+	f.Nulls = values.Get("nulls")
 
-	for k, v := range values {
-		matches := paramFilter.FindStringSubmatch(k)
-		if len(matches) != 4 || matches[1] != "filter" {
-			continue
+	for _, field := range strings.Split(values.Get("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			f.Fields = append(f.Fields, field)
 		}
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		f.Cursor, err = decodeCursor(cursor)
+		if err != nil {
+			f.errs = append(f.errs, ErrInvalidParam{Param: "cursor", Value: cursor, Reason: err.Error()})
+		}
+	}
 
-		switch matches[3] {
-		case "like":
-			f.Filters = append(f.Filters, Like{matches[2], v[0]})
-		case "eq":
-			f.Filters = append(f.Filters, EQ{matches[2], v[0]})
-		case "gt":
-			f.Filters = append(f.Filters, GT{matches[2], v[0]})
-		case "gte":
-			f.Filters = append(f.Filters, GTE{matches[2], v[0]})
-		case "lt":
-			f.Filters = append(f.Filters, LT{matches[2], v[0]})
-		case "lte":
-			f.Filters = append(f.Filters, LTE{matches[2], v[0]})
-		case "contain":
-			f.Filters = append(f.Filters, Contain{matches[2], v[0]})
-		default:
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := newFilterGroup(groupAND, false)
+	for _, k := range keys {
+		if !paramFilter.MatchString(k) {
+			if strings.HasPrefix(k, "filter[") {
+				f.errs = append(f.errs, ErrInvalidParam{Param: k, Reason: "invalid filter"})
+			}
 			continue
 		}
+		var path []string
+		for _, m := range paramSegment.FindAllStringSubmatch(k, -1) {
+			path = append(path, m[1])
+		}
+		for _, v := range values[k] {
+			err := root.add(path, v)
+			var errParam ErrInvalidParam
+			switch {
+			case errors.As(err, &errParam):
+				f.errs = append(f.errs, errParam)
+			case err != nil:
+				f.errs = append(f.errs, ErrInvalidParam{Param: k, Value: v, Reason: err.Error()})
+			}
+		}
+	}
+	fs := root.filters()
+	if q := values.Get("q"); q != "" {
+		fs = append(fs, Search{Value: q})
+	}
+	if len(fs) > 0 {
+		f.Filters = fs
 	}
 
 	return f
 }
 
+// newFilter return the filter of type op on the field param.
+func newFilter(param, op, value string) (Filter, error) {
+	switch op {
+	case "like":
+		return Like{param, value}, nil
+	case "eq":
+		return EQ{param, value}, nil
+	case "ne":
+		return NE{param, value}, nil
+	case "gt":
+		return GT{param, value}, nil
+	case "gte":
+		return GTE{param, value}, nil
+	case "lt":
+		return LT{param, value}, nil
+	case "lte":
+		return LTE{param, value}, nil
+	case "contain":
+		return Contain{param, value}, nil
+	case "containsAll":
+		return ContainsAll{param, strings.Split(value, ",")}, nil
+	case "overlaps":
+		return Overlaps{param, strings.Split(value, ",")}, nil
+	case "in":
+		return In{param, strings.Split(value, ",")}, nil
+	case "nin":
+		return NotIn{param, strings.Split(value, ",")}, nil
+	case "between":
+		from, to, ok := strings.Cut(value, ",")
+		if !ok || strings.Contains(to, ",") {
+			return nil, ErrInvalidParam{Param: param, Op: op, Value: value, Reason: "between requires two comma separated values"}
+		}
+		return Between{param, from, to}, nil
+	case "isnull":
+		return IsNull{param, value}, nil
+	case "startswith":
+		return StartsWith{param, value}, nil
+	case "regex":
+		return Regex{param, value}, nil
+	default:
+		return nil, ErrInvalidParam{Param: param, Op: op, Value: value, Reason: "unknown operator"}
+	}
+}
+
 // Filters for filtering result values.
 type Filters []Filter
 
 // Keep return true if the struct v has valid fields value according to applayable filters.
+// v may also be a map with string keys or a raw JSON document ([]byte or json.RawMessage),
+// whose fields are addressed by path ('address.city', 'tags.0'), see lookupDocument for the
+// coercion rules. Missing fields of documents are null. It returns an error if the operator of a
+// filter is not allowed on a field of a struct by its tag (see NewSchema).
 func (fs Filters) Keep(v any) (bool, error) {
 	for _, f := range fs {
 		keep, err := f.Keep(v)
@@ -91,40 +217,18 @@ func (fs Filters) Keep(v any) (bool, error) {
 }
 
 // AddToPSQLQuery add filter to a pq query.
-// SELECT *, count(*) OVER() FROM (<query>) AS query WHERE <filter1> ILIKE <value1> AND ... ORDER BY <sortBy> <order> LIMIT 1 OFFSET 1
-func AddToPSQLQuery(query string, opt Option) string {
-	filterStr := ""
-	where := make([]string, len(opt.Filters))
-	i := 0
-	for _, fv := range opt.Filters {
-		where[i] = fv.ToSQL()
-		i++
-	}
-	if len(where) > 0 {
-		filterStr = fmt.Sprintf("WHERE %s", strings.Join(where, " AND "))
-	}
-	query = fmt.Sprintf("SELECT *, count(*) OVER() FROM (%v) AS query %v", query, filterStr)
-
-	// sorting
-	if opt.SortBy != "" {
-		query = fmt.Sprintf("%v ORDER BY %v %v", query, pq.QuoteIdentifier(opt.SortBy), opt.Order)
-	}
-	// pagination
-	limit := fmt.Sprintf("%v", opt.Limit)
-	if opt.Limit <= 0 {
-		limit = "ALL"
-	}
-	offset := fmt.Sprintf("%v", opt.Offset)
-	if opt.Offset <= 0 {
-		offset = "0"
-	}
-	return fmt.Sprintf("%v LIMIT %v OFFSET %v", query, limit, offset)
+// SELECT *, count(*) OVER() FROM (<query>) AS query WHERE <filter1> ILIKE <value1> AND ... ORDER BY <sortBy1> <order1>, ... LIMIT 1 OFFSET 1
+// With fields, '*' is replaced by the quoted columns of the fields : SELECT "id", "name", count(*) OVER() ...
+// searchFields are the fields searched by a Search filter without fields, see SQLBuilder.SearchFields.
+func AddToPSQLQuery(query string, opt Option, searchFields ...string) string {
+	return (&SQLBuilder{inline: true, SearchFields: searchFields}).Query(query, opt)
 }
 
 // Filter is the interface for a simple filter for filtering result values.
 type Filter interface {
 	Keep(v any) (bool, error)
 	ToSQL() string
+	BuildSQL(b *SQLBuilder) string
 }
 
 // Like filter to test if a string contains a substring.
@@ -135,21 +239,15 @@ type Like struct {
 
 // Keep return true if the struct v has valid fields value according to the filter.
 func (f Like) Keep(v any) (bool, error) {
-	rv := reflect.ValueOf(v)
-	t := rv.Type()
-	if rv.Kind() != reflect.Struct {
-		return false, fmt.Errorf("filter : not a struct (%v)", reflect.TypeOf(v).String())
+	fv, found, err := lookupField(v, f.Param, "like")
+	if !found {
+		return err == nil, err
 	}
-	for i := 0; i < rv.NumField(); i++ {
-		ti := t.Field(i)
-		if f.Param != ti.Name && f.Param != ti.Tag.Get("filter") {
-			continue
-		}
-		if !stringsContainsI(rv.Field(i).String(), f.Value) {
-			return false, nil
-		}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
 	}
-	return true, nil
+	return stringsContainsI(fv.String(), f.Value), nil
 }
 
 func stringsContainsI(s string, substr string) bool {
@@ -158,7 +256,13 @@ func stringsContainsI(s string, substr string) bool {
 
 // ToSQL return a SQL condition to be used in a SQL query : "Param ILIKE %Value%".
 func (f Like) ToSQL() string {
-	return fmt.Sprintf("%s ILIKE %s", pq.QuoteIdentifier(f.Param), pq.QuoteLiteral("%"+f.Value+"%"))
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param ILIKE $n ESCAPE '\'" with %Value% as argument in PostgreSQL,
+// the wildcards of Value being escaped.
+func (f Like) BuildSQL(b *SQLBuilder) string {
+	return b.dialect().ILike(b.Column(f.Param), b.Value("%"+escapeLike(f.Value)+"%"))
 }
 
 // EQ for ==
@@ -169,63 +273,26 @@ type EQ struct {
 
 // Keep return true if the struct v has valid fields value according to the filter.
 func (f EQ) Keep(v any) (bool, error) {
-	rv := reflect.ValueOf(v)
-	t := rv.Type()
-	if rv.Kind() != reflect.Struct {
-		return false, fmt.Errorf("filter : not a struct (%v)", reflect.TypeOf(v).String())
+	fv, found, err := lookupField(v, f.Param, "eq")
+	if !found {
+		return err == nil, err
 	}
-	for i := 0; i < rv.NumField(); i++ {
-		ti := t.Field(i)
-		if f.Param != ti.Name && f.Param != ti.Tag.Get("filter") {
-			continue
-		}
-		switch cv := rv.Field(i).Interface().(type) {
-		case string:
-			if f.Value != rv.Field(i).String() {
-				return false, nil
-			}
-		case int, int8, int16, int32, int64:
-			value, err := strconv.ParseInt(f.Value, 10, 0)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value != rv.Field(i).Int() {
-				return false, nil
-			}
-		case float32, float64:
-			value, err := strconv.ParseFloat(f.Value, 64)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value != rv.Field(i).Float() {
-				return false, nil
-			}
-		case bool:
-			value, err := strconv.ParseBool(f.Value)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value != rv.Field(i).Bool() {
-				return false, nil
-			}
-		case time.Time:
-			value, err := time.Parse(time.RFC3339, f.Value)
-			if err != nil {
-				return false, ErrParamType{f.Param, fmt.Errorf("%w : supported format time.RFC3339", err)}
-			}
-			if !value.Equal(cv) {
-				return false, nil
-			}
-		default:
-			return false, fmt.Errorf("filter : unsuported field type (%v as %v)", ti.Name, ti.Type.String())
-		}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
 	}
-	return true, nil
+	c, err := compareField(f.Param, "eq", fv, f.Value)
+	return c == 0 && err == nil, err
 }
 
 // ToSQL return a SQL condition to be used in a SQL query : "Param = Value".
 func (f EQ) ToSQL() string {
-	return fmt.Sprintf("%s = %s", pq.QuoteIdentifier(f.Param), pq.QuoteLiteral(f.Value))
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param = $n".
+func (f EQ) BuildSQL(b *SQLBuilder) string {
+	return fmt.Sprintf("%s = %s", b.Column(f.Param), b.FieldValue(f.Param, f.Value))
 }
 
 // GT for >
@@ -236,51 +303,26 @@ type GT struct {
 
 // Keep return true if the struct v has valid fields value according to the filter.
 func (f GT) Keep(v any) (bool, error) {
-	rv := reflect.ValueOf(v)
-	t := rv.Type()
-	if rv.Kind() != reflect.Struct {
-		return false, fmt.Errorf("filter : not a struct (%v)", reflect.TypeOf(v).String())
+	fv, found, err := lookupField(v, f.Param, "gt")
+	if !found {
+		return err == nil, err
 	}
-	for i := 0; i < rv.NumField(); i++ {
-		ti := t.Field(i)
-		if f.Param != ti.Name && f.Param != ti.Tag.Get("filter") {
-			continue
-		}
-		switch cv := rv.Field(i).Interface().(type) {
-		case int, int8, int16, int32, int64:
-			value, err := strconv.ParseInt(f.Value, 10, 0)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value >= rv.Field(i).Int() {
-				return false, nil
-			}
-		case float32, float64:
-			value, err := strconv.ParseFloat(f.Value, 64)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value >= rv.Field(i).Float() {
-				return false, nil
-			}
-		case time.Time:
-			value, err := time.Parse(time.RFC3339, f.Value)
-			if err != nil {
-				return false, ErrParamType{f.Param, fmt.Errorf("%w : supported format time.RFC3339", err)}
-			}
-			if value.After(cv) || value.Equal(cv) {
-				return false, nil
-			}
-		default:
-			return false, fmt.Errorf("filter : unsuported field type (%v as %v)", ti.Name, ti.Type.String())
-		}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
 	}
-	return true, nil
+	c, err := compareField(f.Param, "gt", fv, f.Value)
+	return c > 0 && err == nil, err
 }
 
 // ToSQL return a SQL condition to be used in a SQL query : "Param > Value".
 func (f GT) ToSQL() string {
-	return fmt.Sprintf("%s > %s", pq.QuoteIdentifier(f.Param), pq.QuoteLiteral(f.Value))
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param > $n".
+func (f GT) BuildSQL(b *SQLBuilder) string {
+	return fmt.Sprintf("%s > %s", b.Column(f.Param), b.FieldValue(f.Param, f.Value))
 }
 
 // GTE for >=
@@ -291,51 +333,26 @@ type GTE struct {
 
 // Keep return true if the struct v has valid fields value according to the filter.
 func (f GTE) Keep(v any) (bool, error) {
-	rv := reflect.ValueOf(v)
-	t := rv.Type()
-	if rv.Kind() != reflect.Struct {
-		return false, fmt.Errorf("filter : not a struct (%v)", reflect.TypeOf(v).String())
+	fv, found, err := lookupField(v, f.Param, "gte")
+	if !found {
+		return err == nil, err
 	}
-	for i := 0; i < rv.NumField(); i++ {
-		ti := t.Field(i)
-		if f.Param != ti.Name && f.Param != ti.Tag.Get("filter") {
-			continue
-		}
-		switch cv := rv.Field(i).Interface().(type) {
-		case int, int8, int16, int32, int64:
-			value, err := strconv.ParseInt(f.Value, 10, 0)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value > rv.Field(i).Int() {
-				return false, nil
-			}
-		case float32, float64:
-			value, err := strconv.ParseFloat(f.Value, 64)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value > rv.Field(i).Float() {
-				return false, nil
-			}
-		case time.Time:
-			value, err := time.Parse(time.RFC3339, f.Value)
-			if err != nil {
-				return false, ErrParamType{f.Param, fmt.Errorf("%w : supported format time.RFC3339", err)}
-			}
-			if value.After(cv) {
-				return false, nil
-			}
-		default:
-			return false, fmt.Errorf("filter : unsuported field type (%v as %v)", ti.Name, ti.Type.String())
-		}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
 	}
-	return true, nil
+	c, err := compareField(f.Param, "gte", fv, f.Value)
+	return c >= 0 && err == nil, err
 }
 
 // ToSQL return a SQL condition to be used in a SQL query : "Param >= Value".
 func (f GTE) ToSQL() string {
-	return fmt.Sprintf("%s >= %s", pq.QuoteIdentifier(f.Param), pq.QuoteLiteral(f.Value))
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param >= $n".
+func (f GTE) BuildSQL(b *SQLBuilder) string {
+	return fmt.Sprintf("%s >= %s", b.Column(f.Param), b.FieldValue(f.Param, f.Value))
 }
 
 // LT for <
@@ -346,51 +363,26 @@ type LT struct {
 
 // Keep return true if the struct v has valid fields value according to the filter.
 func (f LT) Keep(v any) (bool, error) {
-	rv := reflect.ValueOf(v)
-	t := rv.Type()
-	if rv.Kind() != reflect.Struct {
-		return false, fmt.Errorf("filter : not a struct (%v)", reflect.TypeOf(v).String())
+	fv, found, err := lookupField(v, f.Param, "lt")
+	if !found {
+		return err == nil, err
 	}
-	for i := 0; i < rv.NumField(); i++ {
-		ti := t.Field(i)
-		if f.Param != ti.Name && f.Param != ti.Tag.Get("filter") {
-			continue
-		}
-		switch cv := rv.Field(i).Interface().(type) {
-		case int, int8, int16, int32, int64:
-			value, err := strconv.ParseInt(f.Value, 10, 0)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value <= rv.Field(i).Int() {
-				return false, nil
-			}
-		case float32, float64:
-			value, err := strconv.ParseFloat(f.Value, 64)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value <= rv.Field(i).Float() {
-				return false, nil
-			}
-		case time.Time:
-			value, err := time.Parse(time.RFC3339, f.Value)
-			if err != nil {
-				return false, ErrParamType{f.Param, fmt.Errorf("%w : supported format time.RFC3339", err)}
-			}
-			if value.Before(cv) || value.Equal(cv) {
-				return false, nil
-			}
-		default:
-			return false, fmt.Errorf("filter : unsuported field type (%v as %v)", ti.Name, ti.Type.String())
-		}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
 	}
-	return true, nil
+	c, err := compareField(f.Param, "lt", fv, f.Value)
+	return c < 0 && err == nil, err
 }
 
 // ToSQL return a SQL condition to be used in a SQL query : "Param < Value".
 func (f LT) ToSQL() string {
-	return fmt.Sprintf("%s < %s", pq.QuoteIdentifier(f.Param), pq.QuoteLiteral(f.Value))
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param < $n".
+func (f LT) BuildSQL(b *SQLBuilder) string {
+	return fmt.Sprintf("%s < %s", b.Column(f.Param), b.FieldValue(f.Param, f.Value))
 }
 
 // LTE for <=
@@ -401,53 +393,30 @@ type LTE struct {
 
 // Keep return true if the struct v has valid fields value according to the filter.
 func (f LTE) Keep(v any) (bool, error) {
-	rv := reflect.ValueOf(v)
-	t := rv.Type()
-	if rv.Kind() != reflect.Struct {
-		return false, fmt.Errorf("filter : not a struct (%v)", reflect.TypeOf(v).String())
+	fv, found, err := lookupField(v, f.Param, "lte")
+	if !found {
+		return err == nil, err
 	}
-	for i := 0; i < rv.NumField(); i++ {
-		ti := t.Field(i)
-		if f.Param != ti.Name && f.Param != ti.Tag.Get("filter") {
-			continue
-		}
-		switch cv := rv.Field(i).Interface().(type) {
-		case int, int8, int16, int32, int64:
-			value, err := strconv.ParseInt(f.Value, 10, 0)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value < rv.Field(i).Int() {
-				return false, nil
-			}
-		case float32, float64:
-			value, err := strconv.ParseFloat(f.Value, 64)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value < rv.Field(i).Float() {
-				return false, nil
-			}
-		case time.Time:
-			value, err := time.Parse(time.RFC3339, f.Value)
-			if err != nil {
-				return false, ErrParamType{f.Param, fmt.Errorf("%w : supported format time.RFC3339", err)}
-			}
-			if value.Before(cv) {
-				return false, nil
-			}
-		default:
-			return false, fmt.Errorf("filter : unsuported field type (%v as %v)", ti.Name, ti.Type.String())
-		}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
 	}
-	return true, nil
+	c, err := compareField(f.Param, "lte", fv, f.Value)
+	return c <= 0 && err == nil, err
 }
 
 // ToSQL return a SQL condition to be used in a SQL query : "Param <= Value".
 func (f LTE) ToSQL() string {
-	return fmt.Sprintf("%s <= %s", pq.QuoteIdentifier(f.Param), pq.QuoteLiteral(f.Value))
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param <= $n".
+func (f LTE) BuildSQL(b *SQLBuilder) string {
+	return fmt.Sprintf("%s <= %s", b.Column(f.Param), b.FieldValue(f.Param, f.Value))
 }
 
+// Contain filter to test if a list contains a value. The list is a slice, an array, or a comma
+// separated list string. Other values are a single element list.
 type Contain struct {
 	Param string
 	Value string
@@ -455,52 +424,39 @@ type Contain struct {
 
 // Keep return true if the struct v has valid fields value according to the filter.
 func (f Contain) Keep(v any) (bool, error) {
-	rv := reflect.ValueOf(v)
-	t := rv.Type()
-	if rv.Kind() != reflect.Struct {
-		return false, fmt.Errorf("filter : not a struct (%v)", reflect.TypeOf(v).String())
+	fv, found, err := lookupField(v, f.Param, "contain")
+	if !found {
+		return err == nil, err
 	}
-	for i := 0; i < rv.NumField(); i++ {
-		ti := t.Field(i)
-		if f.Param != ti.Name && f.Param != ti.Tag.Get("filter") {
-			continue
-		}
-		switch rv.Field(i).Interface().(type) {
-		// kv value is an array with , as separator (x,x,x,x,...)
-		case string:
-			values := strings.Split(rv.Field(i).String(), ",")
-			for _, elem := range values {
-				if f.Value == elem {
-					return true, nil
-				}
-			}
-			return false, nil
-		case int, int8, int16, int32, int64:
-			value, err := strconv.ParseInt(f.Value, 10, 0)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value != rv.Field(i).Int() {
-				return false, nil
-			}
-		case float32, float64:
-			value, err := strconv.ParseFloat(f.Value, 64)
-			if err != nil {
-				return false, ErrParamType{f.Param, err}
-			}
-			if value != rv.Field(i).Float() {
-				return false, nil
-			}
-		default:
-			return false, fmt.Errorf("filter : unsuported field type (%v as %v)", ti.Name, ti.Type.String())
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
+	}
+	if k := kindOf(fv.Type()); k == kindString || k == kindList {
+		// the value is a slice, or a list with , as separator (x,x,x,x,...)
+		test, err := compileList(f.Param, "contain", fv.Type(), []string{f.Value}, time.Now())
+		if err != nil {
+			return false, err
 		}
+		return test(fv), nil
 	}
-	return true, nil
+	// other values are a single element list
+	c, err := compareField(f.Param, "contain", fv, f.Value)
+	return c == 0 && err == nil, err
 }
 
-// ToSQL return a SQL condition to be used in a SQL query : "Param <= Value".
+// ToSQL return a SQL condition to be used in a SQL query : "string_to_array(Param, ',') @> ARRAY[Value]".
 func (f Contain) ToSQL() string {
-	return fmt.Sprintf("string_to_array(%s, ',') @> %v", pq.QuoteIdentifier(f.Param), pq.QuoteLiteral("{"+f.Value+"}"))
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "string_to_array(Param, ',') @> ARRAY[$n]" in PostgreSQL,
+// or "Param @> ARRAY[$n]" on array columns with SQLBuilder.Arrays.
+func (f Contain) BuildSQL(b *SQLBuilder) string {
+	if b.Arrays {
+		return b.dialect().ArrayContains(b.Column(f.Param), valueList(b, f.Param, []string{f.Value}))
+	}
+	return b.dialect().Contains(b.Column(f.Param), b.Value(f.Value))
 }
 
 // An ErrParamType is returned when failed to convert to a certain type a value of a filter.
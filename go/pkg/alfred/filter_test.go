@@ -35,7 +35,7 @@ func TestToSQL(t *testing.T) {
 		filter Filter
 		want   string
 	}{
-		"like": {filter: Like{"name", "batman"}, want: `"name" ILIKE '%batman%'`},
+		"like": {filter: Like{"name", "batman"}, want: `"name" ILIKE '%batman%' ESCAPE '\'`},
 		"eq":   {filter: EQ{"name", "batman"}, want: `"name" = 'batman'`},
 		"gt":   {filter: GT{"age", "42"}, want: `"age" > '42'`},
 		"gte":  {filter: GTE{"age", "42"}, want: `"age" >= '42'`},
@@ -0,0 +1,129 @@
+package alfred
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+type optionKey struct{}
+
+// NewContext return a copy of ctx holding the option opt.
+func NewContext(ctx context.Context, opt Option) context.Context {
+	return context.WithValue(ctx, optionKey{}, opt)
+}
+
+// FromContext return the option held by ctx, as set by Middleware.
+func FromContext(ctx context.Context) (Option, bool) {
+	opt, ok := ctx.Value(optionKey{}).(Option)
+	return opt, ok
+}
+
+// Middleware return a middleware parsing the option of the requests from their url query
+// with ParseURLValues, and storing it in the request context (see FromContext), with the time
+// of the request as Now. The option is validated against schema, requests with invalid
+// parameters are rejected with a 400 Bad Request problem details response (RFC 9457), and
+// its SQL queries use the schema as Schema.ParseURLValues. A nil schema only rejects invalid
+// parameters dropped when parsing.
+func Middleware(schema *Schema) func(http.Handler) http.Handler {
+	return MiddlewareWith(Brackets{}, schema)
+}
+
+// MiddlewareWith is Middleware parsing the option of the requests with parser. Public endpoints
+// should bound the pages of the requests with a Limits parser. The URLs of the option are encoded
+// by parser (see Option.URL).
+func MiddlewareWith(parser Parser, schema *Schema) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			opt := parser.Parse(r.URL.RawQuery)
+			// the same instant for the relative times of the request
+			opt.Now = time.Now()
+			opt.parser = parser
+			var err error
+			if schema != nil {
+				opt = schema.restrict(opt)
+				err = opt.Validate(schema)
+			} else if len(opt.errs) > 0 {
+				err = opt.errs
+			}
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), opt)))
+		})
+	}
+}
+
+// Problem is a problem details response body (RFC 9457), with the invalid parameters of a request.
+type Problem struct {
+	Type          string            `json:"type"`
+	Title         string            `json:"title"`
+	Status        int               `json:"status"`
+	Detail        string            `json:"detail,omitempty"`
+	InvalidParams []ErrInvalidParam `json:"invalid_params,omitempty"`
+}
+
+// WriteError write err as a 400 Bad Request problem details response, with the
+// invalid parameters of err if it is an ErrValidation or an ErrInvalidParam.
+func WriteError(w http.ResponseWriter, err error) {
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+		Detail: err.Error(),
+	}
+	var errValidation ErrValidation
+	var errParam ErrInvalidParam
+	switch {
+	case errors.As(err, &errValidation):
+		problem.Detail = "invalid query parameters"
+		problem.InvalidParams = errValidation
+	case errors.As(err, &errParam):
+		problem.Detail = "invalid query parameters"
+		problem.InvalidParams = []ErrInvalidParam{errParam}
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// Page is the response envelope of a page of items.
+type Page struct {
+	Data   any `json:"data"`
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// WritePage write the page of items as a JSON Page, given the total number of items as returned
+// by Apply or the 'count(*) OVER()' column of AddToPSQLQuery. The total is also written in the
+// X-Total-Count header, and the links to the other pages in the Link header (see Option.Links).
+// With fields, the items are projected to the fields (see Option.Project).
+func WritePage(w http.ResponseWriter, r *http.Request, opt Option, items any, total int) error {
+	if len(opt.Fields) > 0 {
+		projected, err := opt.projectItems(items)
+		if err != nil {
+			return err
+		}
+		items = projected
+	}
+	links, err := opt.Links(r.URL, total)
+	if err != nil {
+		return err
+	}
+	if header := links.Header(); header != "" {
+		w.Header().Set("Link", header)
+	}
+	if v := reflect.ValueOf(items); v.Kind() == reflect.Slice && v.IsNil() {
+		// an empty page, not a null one
+		items = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(Page{Data: items, Total: total, Limit: opt.Limit, Offset: opt.Offset})
+}
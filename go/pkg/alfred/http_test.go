@@ -0,0 +1,113 @@
+package alfred
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type httpHero struct {
+	Name string `filter:"name" json:"name"`
+	Age  int    `filter:"age" json:"age"`
+}
+
+var httpHeroes = []httpHero{{"Bruce", 42}, {"Clark", 35}, {"Diana", 5000}, {"Barry", 28}}
+
+func httpHandler(t *testing.T) http.Handler {
+	return Middleware(NewSchema(httpHero{}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opt, ok := FromContext(r.Context())
+		require.True(t, ok)
+		page, total, err := Apply(httpHeroes, opt)
+		require.NoError(t, err)
+		require.NoError(t, WritePage(w, r, opt, page, total))
+	}))
+}
+
+func TestMiddleware(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httpHandler(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?sortBy=name&limit=2&offset=1&filter[age][lt]=100", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "3", rec.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</heroes?filter%5Bage%5D%5Blt%5D=100&limit=2&sortBy=name>; rel="first", `+
+		`</heroes?filter%5Bage%5D%5Blt%5D=100&limit=2&sortBy=name>; rel="prev", `+
+		`</heroes?filter%5Bage%5D%5Blt%5D=100&limit=2&offset=2&sortBy=name>; rel="last"`, rec.Header().Get("Link"))
+	assert.JSONEq(t, `{"data":[{"name":"Bruce","age":42},{"name":"Clark","age":35}],"total":3,"limit":2,"offset":1}`, rec.Body.String())
+}
+
+func TestMiddlewareEmptyPage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httpHandler(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?filter[name][eq]=Tony", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-Total-Count"))
+	assert.JSONEq(t, `{"data":[],"total":0,"limit":0,"offset":0}`, rec.Body.String())
+}
+
+func TestMiddlewareInvalid(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httpHandler(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?limit=ten&filter[power][eq]=flight&filter[age][like]=4", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, Problem{
+		Type:   "about:blank",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Detail: "invalid query parameters",
+		InvalidParams: []ErrInvalidParam{
+			{Param: "limit", Value: "ten", Reason: "invalid integer"},
+			{Param: "age", Op: "like", Value: "4", Reason: "operator not supported on int field"},
+			{Param: "power", Op: "eq", Value: "flight", Reason: "unknown field"},
+		},
+	}, problem)
+}
+
+func TestMiddlewareWithoutSchema(t *testing.T) {
+	handler := Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opt, _ := FromContext(r.Context())
+		assert.Equal(t, Filters{EQ{"power", "flight"}}, opt.Filters)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?filter[power][eq]=flight", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?filter[power][between]=1", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestFromContextMissing(t *testing.T) {
+	_, ok := FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	assert.False(t, ok)
+}
+
+func TestMiddlewareNotAllowed(t *testing.T) {
+	handler := Middleware(NewSchema(tagHero{}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opt, _ := FromContext(r.Context())
+		assert.Equal(t, `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE "hero_name" = 'Bruce' LIMIT ALL OFFSET 0`,
+			AddToPSQLQuery("SELECT * FROM heroes", opt))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?filter[name][eq]=Bruce", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?filter[team][eq]=jla&sortBy=secret", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var problem Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, []ErrInvalidParam{
+		{Param: "sortBy", Value: "secret", Reason: "sorting not allowed"},
+		{Param: "team", Op: "eq", Value: "jla", Reason: "operator not allowed"},
+	}, problem.InvalidParams)
+}
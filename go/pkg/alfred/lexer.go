@@ -0,0 +1,126 @@
+package alfred
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxError is an error in a filter expression, at a position of the expression.
+type SyntaxError struct {
+	// Pos is the position of the error in the expression, starting at 1.
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d : %s", e.Pos, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// A token of a filter expression. Pos is its byte offset in the expression.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of input"
+	case tokenString:
+		return "'" + strings.ReplaceAll(t.text, "'", "''") + "'"
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+// lex split the expression in tokens, ended by a tokenEOF. Words are runs of characters other than
+// spaces, parentheses and commas. With quotes, single quoted strings are tokenString, a quote being
+// escaped by doubling it ('O''Neil'), otherwise quotes are part of words.
+func lex(input string, quotes bool) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+		case c == '\'' && quotes:
+			start := i
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(input) {
+					return nil, &SyntaxError{Pos: start + 1, Msg: "unterminated string"}
+				}
+				if input[i] == '\'' {
+					if i+1 < len(input) && input[i+1] == '\'' {
+						i++
+					} else {
+						break
+					}
+				}
+				sb.WriteByte(input[i])
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+		default:
+			start := i
+			for i < len(input) && !strings.ContainsRune(" \t\n\r(),", rune(input[i])) && !(quotes && input[i] == '\'') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: input[start:i], pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input)}), nil
+}
+
+// tokenReader reads the tokens of an expression.
+type tokenReader struct {
+	tokens []token
+	i      int
+}
+
+func (r *tokenReader) peek() token {
+	return r.tokens[r.i]
+}
+
+func (r *tokenReader) next() token {
+	t := r.tokens[r.i]
+	if t.kind != tokenEOF {
+		r.i++
+	}
+	return t
+}
+
+// expect read the next token, it returns an error if it is not of the kind.
+func (r *tokenReader) expect(kind tokenKind, what string) (token, error) {
+	t := r.next()
+	if t.kind != kind {
+		return t, errorAt(t, "expected %v, got %v", what, t)
+	}
+	return t, nil
+}
+
+// errorAt return a SyntaxError at the position of the token t.
+func errorAt(t token, format string, args ...any) error {
+	return &SyntaxError{Pos: t.pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,38 @@
+package alfred
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLex(t *testing.T) {
+	tokens, err := lex(`name eq 'O''Neil' and (age in (1, 2))`, true)
+	require.NoError(t, err)
+	assert.Equal(t, []token{
+		{kind: tokenWord, text: "name", pos: 0},
+		{kind: tokenWord, text: "eq", pos: 5},
+		{kind: tokenString, text: "O'Neil", pos: 8},
+		{kind: tokenWord, text: "and", pos: 18},
+		{kind: tokenLParen, text: "(", pos: 22},
+		{kind: tokenWord, text: "age", pos: 23},
+		{kind: tokenWord, text: "in", pos: 27},
+		{kind: tokenLParen, text: "(", pos: 30},
+		{kind: tokenWord, text: "1", pos: 31},
+		{kind: tokenComma, text: ",", pos: 32},
+		{kind: tokenWord, text: "2", pos: 34},
+		{kind: tokenRParen, text: ")", pos: 35},
+		{kind: tokenRParen, text: ")", pos: 36},
+		{kind: tokenEOF, pos: 37},
+	}, tokens)
+
+	tokens, err = lex(`eq(name,O'Neil)`, false)
+	require.NoError(t, err)
+	assert.Equal(t, token{kind: tokenWord, text: "O'Neil", pos: 8}, tokens[4])
+}
+
+func TestLexUnterminatedString(t *testing.T) {
+	_, err := lex(`name eq 'Bruce`, true)
+	assert.EqualError(t, err, "syntax error at position 9 : unterminated string")
+}
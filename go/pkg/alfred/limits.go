@@ -0,0 +1,104 @@
+package alfred
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Limits is a pagination policy bounding the options parsed by Parser, so that a client cannot
+// scan a whole table : Limits{MaxLimit: 100, MaxOffset: 10000, MaxFilters: 10}. A zero bound is
+// no bound. Options exceeding a bound are clamped to it, and with Reject the invalid parameter is
+// also reported by Option.Validate, so that Middleware rejects the request. Filters cannot be
+// clamped without widening the results : too many filters are always reported.
+// Limits is a Parser, see MiddlewareWith.
+type Limits struct {
+	// Parser of the options, Brackets if nil.
+	Parser Parser
+	// DefaultLimit is the limit of options without limit, MaxLimit if zero.
+	DefaultLimit int
+	// MaxLimit is the maximum limit.
+	MaxLimit int
+	// MaxOffset is the maximum offset.
+	MaxOffset int
+	// MaxFilters is the maximum number of filters, counting the filters of groups.
+	MaxFilters int
+	// Reject reports the options exceeding the limits, instead of only clamping them.
+	Reject bool
+}
+
+// Parse the raw query with the parser of the limits, and enforce the limits on the option.
+func (l Limits) Parse(rawQuery string) Option {
+	return l.Enforce(l.parser().Parse(rawQuery))
+}
+
+// Encode return the option in the syntax of the parser of the limits.
+func (l Limits) Encode(rawQuery string, opt Option) (string, error) {
+	return l.parser().Encode(rawQuery, opt)
+}
+
+// parser return the parser of the limits.
+func (l Limits) parser() Parser {
+	if l.Parser == nil {
+		return Brackets{}
+	}
+	return l.Parser
+}
+
+// Enforce return the option within the limits. Options without limit, or with a negative limit, get
+// the default limit. Limits and offsets above their maximum are clamped to it. With Reject, a negative
+// limit and the bounds exceeded are reported by Option.Validate. More than MaxFilters filters are
+// always reported, and kept : leaving filters out would return results the client filtered out.
+func (l Limits) Enforce(opt Option) Option {
+	opt.errs = slices.Clone(opt.errs)
+	reject := func(param, value, reason string) {
+		if l.Reject {
+			opt.errs = append(opt.errs, ErrInvalidParam{Param: param, Value: value, Reason: reason})
+		}
+	}
+
+	if opt.Limit < 0 {
+		reject("limit", strconv.Itoa(opt.Limit), "must be positive")
+	}
+	if opt.Limit <= 0 {
+		opt.Limit = l.DefaultLimit
+		if opt.Limit <= 0 || (l.MaxLimit > 0 && opt.Limit > l.MaxLimit) {
+			opt.Limit = l.MaxLimit
+		}
+	}
+	if l.MaxLimit > 0 && opt.Limit > l.MaxLimit {
+		reject("limit", strconv.Itoa(opt.Limit), fmt.Sprintf("must be at most %d", l.MaxLimit))
+		opt.Limit = l.MaxLimit
+	}
+	if l.MaxOffset > 0 && opt.Offset > l.MaxOffset {
+		reject("offset", strconv.Itoa(opt.Offset), fmt.Sprintf("must be at most %d", l.MaxOffset))
+		opt.Offset = l.MaxOffset
+	}
+
+	if n := countFilters(opt.Filters); l.MaxFilters > 0 && n > l.MaxFilters {
+		opt.errs = append(opt.errs, ErrInvalidParam{
+			Param:  "filter",
+			Value:  strconv.Itoa(n),
+			Reason: fmt.Sprintf("too many filters, must be at most %d", l.MaxFilters),
+		})
+	}
+	return opt
+}
+
+// countFilters return the number of filters of fs, counting the filters of groups instead of the groups.
+func countFilters(fs Filters) int {
+	n := 0
+	for _, f := range fs {
+		switch f := f.(type) {
+		case And:
+			n += countFilters(Filters(f))
+		case Or:
+			n += countFilters(Filters(f))
+		case Not:
+			n += countFilters(Filters{f.Filter})
+		default:
+			n++
+		}
+	}
+	return n
+}
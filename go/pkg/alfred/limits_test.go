@@ -0,0 +1,107 @@
+package alfred
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsEnforce(t *testing.T) {
+	limits := Limits{DefaultLimit: 20, MaxLimit: 100, MaxOffset: 1000, MaxFilters: 3}
+	tests := map[string]struct {
+		limits Limits
+		opt    Option
+		want   Option
+		errs   ErrValidation
+	}{
+		"within":        {limits: limits, opt: Option{Limit: 50, Offset: 100}, want: Option{Limit: 50, Offset: 100}},
+		"default":       {limits: limits, opt: Option{}, want: Option{Limit: 20}},
+		"default-max":   {limits: Limits{MaxLimit: 100}, opt: Option{}, want: Option{Limit: 100}},
+		"default-above": {limits: Limits{DefaultLimit: 500, MaxLimit: 100}, opt: Option{}, want: Option{Limit: 100}},
+		"no-limits":     {limits: Limits{}, opt: Option{Limit: -1, Offset: 1e9}, want: Option{Limit: 0, Offset: 1e9}},
+		"negative":      {limits: limits, opt: Option{Limit: -1}, want: Option{Limit: 20}},
+		"clamp-limit":   {limits: limits, opt: Option{Limit: 1e6}, want: Option{Limit: 100}},
+		"clamp-offset":  {limits: limits, opt: Option{Limit: 10, Offset: 5000}, want: Option{Limit: 10, Offset: 1000}},
+		"clamp-cursor":  {limits: limits, opt: Option{Limit: 500, Cursor: []string{"a"}}, want: Option{Limit: 100, Cursor: []string{"a"}}},
+		"within-filters": {
+			limits: limits,
+			opt:    Option{Limit: 10, Filters: Filters{EQ{"a", "1"}, Or{EQ{"b", "1"}, Not{EQ{"c", "1"}}}}},
+			want:   Option{Limit: 10, Filters: Filters{EQ{"a", "1"}, Or{EQ{"b", "1"}, Not{EQ{"c", "1"}}}}},
+		},
+		"too-many-filters": {
+			limits: limits,
+			opt:    Option{Limit: 10, Filters: Filters{EQ{"a", "1"}, Or{EQ{"b", "1"}, Not{EQ{"c", "1"}}}, EQ{"d", "1"}}},
+			want:   Option{Limit: 10, Filters: Filters{EQ{"a", "1"}, Or{EQ{"b", "1"}, Not{EQ{"c", "1"}}}, EQ{"d", "1"}}},
+			errs:   ErrValidation{{Param: "filter", Value: "4", Reason: "too many filters, must be at most 3"}},
+		},
+		"too-many-filters-group": {
+			limits: limits,
+			opt:    Option{Limit: 10, Filters: Filters{And{EQ{"a", "1"}, EQ{"b", "1"}, EQ{"c", "1"}, EQ{"d", "1"}}}},
+			want:   Option{Limit: 10, Filters: Filters{And{EQ{"a", "1"}, EQ{"b", "1"}, EQ{"c", "1"}, EQ{"d", "1"}}}},
+			errs:   ErrValidation{{Param: "filter", Value: "4", Reason: "too many filters, must be at most 3"}},
+		},
+		"reject": {
+			limits: Limits{DefaultLimit: 20, MaxLimit: 100, MaxOffset: 1000, MaxFilters: 1, Reject: true},
+			opt:    Option{Limit: 500, Offset: 2000, Filters: Filters{EQ{"a", "1"}, Like{"b", "1"}}},
+			want:   Option{Limit: 100, Offset: 1000, Filters: Filters{EQ{"a", "1"}, Like{"b", "1"}}},
+			errs: ErrValidation{
+				{Param: "limit", Value: "500", Reason: "must be at most 100"},
+				{Param: "offset", Value: "2000", Reason: "must be at most 1000"},
+				{Param: "filter", Value: "2", Reason: "too many filters, must be at most 1"},
+			},
+		},
+		"reject-negative": {
+			limits: Limits{DefaultLimit: 20, Reject: true},
+			opt:    Option{Limit: -5},
+			want:   Option{Limit: 20},
+			errs:   ErrValidation{{Param: "limit", Value: "-5", Reason: "must be positive"}},
+		},
+		"reject-default": {limits: Limits{DefaultLimit: 20, MaxLimit: 100, Reject: true}, opt: Option{}, want: Option{Limit: 20}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opt := tt.limits.Enforce(tt.opt)
+			assert.Equal(t, tt.errs, opt.errs)
+			opt.errs = nil
+			assert.Equal(t, tt.want, opt)
+		})
+	}
+}
+
+func TestLimitsParse(t *testing.T) {
+	opt := Limits{MaxLimit: 50}.Parse("limit=ten&sortBy=name")
+	assert.Equal(t, 50, opt.Limit)
+	assert.Equal(t, "name", opt.SortBy)
+	assert.Equal(t, ErrValidation{{Param: "limit", Value: "ten", Reason: "invalid integer"}}, opt.errs)
+
+	opt = Limits{Parser: OData{}, MaxLimit: 50, MaxOffset: 100}.Parse("$top=1000&$skip=1000")
+	assert.Equal(t, 50, opt.Limit)
+	assert.Equal(t, 100, opt.Offset)
+	assert.Equal(t, `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query  LIMIT 50 OFFSET 100`, AddToPSQLQuery("SELECT * FROM heroes", opt))
+}
+
+func TestMiddlewareLimits(t *testing.T) {
+	handler := MiddlewareWith(Limits{DefaultLimit: 2, MaxLimit: 3, Reject: true}, NewSchema(httpHero{}))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			opt, _ := FromContext(r.Context())
+			page, total, err := Apply(httpHeroes, opt)
+			require.NoError(t, err)
+			require.NoError(t, WritePage(w, r, opt, page, total))
+		}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?sortBy=name", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":[{"name":"Barry","age":28},{"name":"Bruce","age":42}],"total":4,"limit":2,"offset":0}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?limit=1000", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var problem Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, []ErrInvalidParam{{Param: "limit", Value: "1000", Reason: "must be at most 3"}}, problem.InvalidParams)
+}
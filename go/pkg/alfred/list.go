@@ -0,0 +1,143 @@
+package alfred
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// compileList return the test of the list operator op (contain, containsAll or overlaps) with
+// the values on a list field of type t : a slice or an array, or a comma separated list string.
+// Relative times are resolved against now. The test is given the compared value of the field.
+func compileList(param, op string, t reflect.Type, values []string, now time.Time) (func(fv reflect.Value) bool, error) {
+	info := scalarOf(t)
+	var contains []func(fv reflect.Value) bool
+	switch {
+	case info.kind == kindString:
+		for _, value := range values {
+			contains = append(contains, func(fv reflect.Value) bool {
+				return slices.Contains(strings.Split(fv.String(), ","), value)
+			})
+		}
+	case info.kind == kindList && info.elem.kind != kindUnsupported && info.elem.kind != kindList:
+		elem := info.elem
+		for _, value := range values {
+			c, err := compileCompare(elem.kind, value, now)
+			if err != nil {
+				return nil, ErrParamType{param, err}
+			}
+			contains = append(contains, func(fv reflect.Value) bool {
+				for i := 0; i < fv.Len(); i++ {
+					if ev, ok := elem.scalar(fv.Index(i)); ok && c(ev) == 0 {
+						return true
+					}
+				}
+				return false
+			})
+		}
+	default:
+		return nil, fmt.Errorf("filter : unsuported field type (%v as %v)", param, t.String())
+	}
+
+	if op == "overlaps" {
+		return func(fv reflect.Value) bool {
+			return slices.ContainsFunc(contains, func(c func(reflect.Value) bool) bool { return c(fv) })
+		}, nil
+	}
+	return func(fv reflect.Value) bool {
+		for _, c := range contains {
+			if !c(fv) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// keepList return true if the list field param of the struct v matches the list operator op with the values.
+func keepList(v any, param, op string, values []string) (bool, error) {
+	fv, found, err := lookupField(v, param, op)
+	if !found {
+		return err == nil, err
+	}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
+	}
+	test, err := compileList(param, op, fv.Type(), values, time.Now())
+	if err != nil {
+		return false, err
+	}
+	return test(fv), nil
+}
+
+// ContainsAll filter to test if a list contains all the values. The list is a slice, an
+// array, or a comma separated list string.
+type ContainsAll struct {
+	Param  string
+	Values []string
+}
+
+// Keep return true if the struct v has valid fields value according to the filter.
+func (f ContainsAll) Keep(v any) (bool, error) {
+	return keepList(v, f.Param, "containsAll", f.Values)
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "string_to_array(Param, ',') @> ARRAY[Value1] AND ...".
+func (f ContainsAll) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "(string_to_array(Param, ',') @> ARRAY[$1] AND ...)"
+// in PostgreSQL, or "Param @> ARRAY[$1, $2, ...]" on array columns with SQLBuilder.Arrays.
+func (f ContainsAll) BuildSQL(b *SQLBuilder) string {
+	if b.Arrays && len(f.Values) > 0 {
+		return b.dialect().ArrayContains(b.Column(f.Param), valueList(b, f.Param, f.Values))
+	}
+	all := make(And, len(f.Values))
+	for i, value := range f.Values {
+		all[i] = Contain{f.Param, value}
+	}
+	return all.BuildSQL(b)
+}
+
+// Overlaps filter to test if a list contains any of the values. The list is a slice, an
+// array, or a comma separated list string.
+type Overlaps struct {
+	Param  string
+	Values []string
+}
+
+// Keep return true if the struct v has valid fields value according to the filter.
+func (f Overlaps) Keep(v any) (bool, error) {
+	return keepList(v, f.Param, "overlaps", f.Values)
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "string_to_array(Param, ',') @> ARRAY[Value1] OR ...".
+func (f Overlaps) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "(string_to_array(Param, ',') @> ARRAY[$1] OR ...)"
+// in PostgreSQL, or "Param && ARRAY[$1, $2, ...]" on array columns with SQLBuilder.Arrays.
+func (f Overlaps) BuildSQL(b *SQLBuilder) string {
+	if b.Arrays && len(f.Values) > 0 {
+		return b.dialect().ArrayOverlaps(b.Column(f.Param), valueList(b, f.Param, f.Values))
+	}
+	or := make(Or, len(f.Values))
+	for i, value := range f.Values {
+		or[i] = Contain{f.Param, value}
+	}
+	return or.BuildSQL(b)
+}
+
+// valueList add the values of the field param to the arguments and return their placeholders.
+func valueList(b *SQLBuilder, param string, values []string) []string {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = b.FieldValue(param, value)
+	}
+	return placeholders
+}
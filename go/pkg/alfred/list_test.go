@@ -0,0 +1,198 @@
+package alfred
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type listHero struct {
+	ID      int       `filter:"id"`
+	Powers  []string  `filter:"powers"`
+	Years   []int     `filter:"years"`
+	Aliases [2]string `filter:"aliases"`
+	Scores  []*float64
+	Tags    string `filter:"tags"`
+}
+
+var listHeroes = []listHero{
+	{ID: 1, Powers: []string{"rich", "detective"}, Years: []int{1939, 1986}, Aliases: [2]string{"batman", "matches"}, Scores: []*float64{ptr(4.5), nil}, Tags: "rich,detective"},
+	{ID: 2, Powers: []string{"flight", "strength", "heat vision"}, Years: []int{1938}, Aliases: [2]string{"superman"}, Tags: "flight,strength"},
+	{ID: 3, Powers: []string{"flight", "strength"}, Aliases: [2]string{"wonder woman", "diana prince"}, Scores: []*float64{ptr(5.0)}, Tags: "flight"},
+	{ID: 4},
+}
+
+func TestKeepList(t *testing.T) {
+	tests := map[string]struct {
+		filter   Filter
+		expected []int
+	}{
+		"contain":           {Contain{"powers", "flight"}, []int{2, 3}},
+		"contain-int":       {Contain{"years", "1939"}, []int{1}},
+		"contain-array":     {Contain{"aliases", "superman"}, []int{2}},
+		"contain-pointers":  {Contain{"Scores", "5"}, []int{3}},
+		"contain-string":    {Contain{"tags", "strength"}, []int{2}},
+		"containsAll":       {ContainsAll{"powers", []string{"strength", "flight"}}, []int{2, 3}},
+		"containsAll-one":   {ContainsAll{"powers", []string{"heat vision", "flight"}}, []int{2}},
+		"containsAll-empty": {ContainsAll{"powers", nil}, []int{1, 2, 3}},
+		"containsAll-tags":  {ContainsAll{"tags", []string{"flight", "strength"}}, []int{2}},
+		"overlaps":          {Overlaps{"powers", []string{"rich", "heat vision"}}, []int{1, 2}},
+		"overlaps-int":      {Overlaps{"years", []string{"1938", "1986"}}, []int{1, 2}},
+		"overlaps-empty":    {Overlaps{"powers", nil}, nil},
+		"overlaps-tags":     {Overlaps{"tags", []string{"rich", "flight"}}, []int{1, 2, 3}},
+		"isnull":            {IsNull{"powers", "true"}, []int{4}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := Filters{tc.filter}.Compile(listHero{})
+			require.NoError(t, err)
+
+			var kept, compiled []int
+			for _, hero := range listHeroes {
+				keep, err := tc.filter.Keep(hero)
+				assert.NoError(t, err)
+				if keep {
+					kept = append(kept, hero.ID)
+				}
+				keep, err = c.Keep(hero)
+				assert.NoError(t, err)
+				if keep {
+					compiled = append(compiled, hero.ID)
+				}
+			}
+			assert.Equal(t, tc.expected, kept)
+			assert.Equal(t, tc.expected, compiled)
+		})
+	}
+}
+
+func TestKeepListErrors(t *testing.T) {
+	_, err := Contain{"years", "recent"}.Keep(listHeroes[0])
+	assert.EqualError(t, err, `filter: field 'years' : strconv.ParseInt: parsing "recent": invalid syntax`)
+	_, err = Overlaps{"id", []string{"1"}}.Keep(listHeroes[0])
+	assert.EqualError(t, err, "filter : unsuported field type (id as int)")
+}
+
+func TestParseList(t *testing.T) {
+	opt := ParseURLValues(url.Values{
+		"filter[powers][containsAll]": {"flight,strength"},
+		"filter[years][overlaps]":     {"1938,1939"},
+	})
+	assert.Equal(t, Filters{
+		ContainsAll{"powers", []string{"flight", "strength"}},
+		Overlaps{"years", []string{"1938", "1939"}},
+	}, opt.Filters)
+	assert.NoError(t, opt.Validate(NewSchema(listHero{})))
+
+	err := Option{Filters: Filters{
+		Overlaps{"years", []string{"1938", "recent"}},
+		ContainsAll{"id", []string{"1"}},
+	}}.Validate(NewSchema(listHero{}))
+	assert.Equal(t, ErrValidation{
+		{Param: "years", Op: "overlaps", Value: "1938,recent", Reason: "invalid int value"},
+		{Param: "id", Op: "containsAll", Value: "1", Reason: "operator not supported on int field"},
+	}, err)
+	err = Option{SortBy: "powers"}.Validate(NewSchema(listHero{}))
+	assert.Equal(t, ErrValidation{{Param: "sortBy", Value: "powers", Reason: "field cannot be sorted"}}, err)
+}
+
+func TestListSQL(t *testing.T) {
+	filters := Filters{
+		Contain{"powers", "flight"},
+		ContainsAll{"powers", []string{"flight", "strength"}},
+		Overlaps{"years", []string{"1938", "1939"}},
+	}
+	typed := []any{"flight", "flight", "strength", int64(1938), int64(1939)}
+	tests := map[string]struct {
+		builder *SQLBuilder
+		want    string
+		args    []any
+	}{
+		"list": {
+			builder: &SQLBuilder{Schema: NewSchema(listHero{})},
+			// the elements of comma separated strings are strings
+			args: []any{"flight", "flight", "strength", "1938", "1939"},
+			want: `string_to_array("powers", ',') @> ARRAY[$1] AND ` +
+				`(string_to_array("powers", ',') @> ARRAY[$2] AND string_to_array("powers", ',') @> ARRAY[$3]) AND ` +
+				`(string_to_array("years", ',') @> ARRAY[$4] OR string_to_array("years", ',') @> ARRAY[$5])`,
+		},
+		"postgres": {
+			builder: &SQLBuilder{Arrays: true, Schema: NewSchema(listHero{})},
+			args:    typed,
+			want:    `"powers" @> ARRAY[$1] AND "powers" @> ARRAY[$2, $3] AND "years" && ARRAY[$4, $5]`,
+		},
+		"sqlite": {
+			builder: &SQLBuilder{Dialect: SQLite{}, Arrays: true, Schema: NewSchema(listHero{})},
+			args:    typed,
+			want: `(EXISTS (SELECT 1 FROM json_each("powers") WHERE value = ?)) AND ` +
+				`(EXISTS (SELECT 1 FROM json_each("powers") WHERE value = ?) AND EXISTS (SELECT 1 FROM json_each("powers") WHERE value = ?)) AND ` +
+				`EXISTS (SELECT 1 FROM json_each("years") WHERE value IN (?, ?))`,
+		},
+		"mysql": {
+			builder: &SQLBuilder{Dialect: MySQL{}, Arrays: true, Schema: NewSchema(listHero{})},
+			args:    typed,
+			want:    "JSON_CONTAINS(`powers`, JSON_ARRAY(?)) AND JSON_CONTAINS(`powers`, JSON_ARRAY(?, ?)) AND JSON_OVERLAPS(`years`, JSON_ARRAY(?, ?))",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var where []string
+			for _, f := range filters {
+				where = append(where, f.BuildSQL(tt.builder))
+			}
+			assert.Equal(t, tt.want, strings.Join(where, " AND "))
+			assert.Equal(t, tt.args, tt.builder.Args())
+		})
+	}
+	assert.Equal(t, "TRUE", ContainsAll{"powers", nil}.BuildSQL(&SQLBuilder{Arrays: true}))
+	assert.Equal(t, "FALSE", Overlaps{"powers", nil}.BuildSQL(&SQLBuilder{Arrays: true}))
+}
+
+func TestSQLiteArrays(t *testing.T) {
+	db, err := sql.Open("sqlite3_regexp", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE heroes (id INTEGER PRIMARY KEY, powers TEXT, years TEXT)`)
+	require.NoError(t, err)
+	for _, h := range listHeroes {
+		powers, _ := json.Marshal(h.Powers)
+		years, _ := json.Marshal(h.Years)
+		_, err = db.Exec(`INSERT INTO heroes VALUES (?, ?, ?)`, h.ID, string(powers), string(years))
+		require.NoError(t, err)
+	}
+
+	tests := map[string]Filter{
+		"contain":     Contain{"powers", "flight"},
+		"contain-int": Contain{"years", "1939"},
+		"containsAll": ContainsAll{"powers", []string{"heat vision", "flight"}},
+		"overlaps":    Overlaps{"years", []string{"1938", "1986"}},
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &SQLBuilder{Dialect: SQLite{}, Arrays: true, Schema: NewSchema(listHero{})}
+			rows, err := db.Query("SELECT id FROM heroes WHERE "+f.BuildSQL(b)+" ORDER BY id", b.Args()...)
+			require.NoError(t, err)
+			defer rows.Close()
+			var ids []int
+			for rows.Next() {
+				var id int
+				require.NoError(t, rows.Scan(&id))
+				ids = append(ids, id)
+			}
+			require.NoError(t, rows.Err())
+
+			var want []int
+			for _, hero := range listHeroes {
+				if keep, _ := f.Keep(hero); keep {
+					want = append(want, hero.ID)
+				}
+			}
+			assert.Equal(t, want, ids)
+		})
+	}
+}
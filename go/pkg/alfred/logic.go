@@ -0,0 +1,238 @@
+package alfred
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// And filter to test if all of its filters are true.
+type And []Filter
+
+// Keep return true if the struct v is kept by every filter.
+func (f And) Keep(v any) (bool, error) {
+	return Filters(f).Keep(v)
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "(Filter1 AND Filter2 ...)".
+func (f And) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "(Filter1 AND Filter2 ...)".
+func (f And) BuildSQL(b *SQLBuilder) string {
+	return joinSQL(b, f, "AND", "TRUE")
+}
+
+// Or filter to test if at least one of its filters is true.
+type Or []Filter
+
+// Keep return true if the struct v is kept by at least one filter.
+func (f Or) Keep(v any) (bool, error) {
+	for _, flt := range f {
+		keep, err := flt.Keep(v)
+		if err != nil {
+			return false, err
+		}
+		if keep {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "(Filter1 OR Filter2 ...)".
+func (f Or) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "(Filter1 OR Filter2 ...)".
+func (f Or) BuildSQL(b *SQLBuilder) string {
+	return joinSQL(b, f, "OR", "FALSE")
+}
+
+// Not filter to negate a filter.
+type Not struct {
+	Filter Filter
+}
+
+// Keep return true if the struct v is not kept by the negated filter. As in SQL, the negation of
+// a filter of a field does not keep the null values of the field (see negate).
+func (f Not) Keep(v any) (bool, error) {
+	not, ok := negate(f.Filter).(Not)
+	if !ok {
+		return negate(f.Filter).Keep(v)
+	}
+	for _, field := range nullableFields(not.Filter) {
+		fv, found, err := lookupField(v, field, "")
+		if err != nil {
+			return false, err
+		}
+		if found && (!fv.IsValid() || isNil(fv)) {
+			return false, nil
+		}
+	}
+	keep, err := not.Filter.Keep(v)
+	if err != nil {
+		return false, err
+	}
+	return !keep, nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "NOT (Filter)".
+func (f Not) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "NOT (Filter)".
+func (f Not) BuildSQL(b *SQLBuilder) string {
+	return fmt.Sprintf("NOT (%s)", f.Filter.BuildSQL(b))
+}
+
+// negate return the negation of f, the Not being pushed down to the filters of fields :
+// Not{And{a, b}} is Or{Not{a}, Not{b}}. As in SQL, where the negation of a comparison with null
+// is null, the negated filters of fields do not match null values (see nullableFields).
+func negate(f Filter) Filter {
+	switch f := f.(type) {
+	case And:
+		or := make(Or, len(f))
+		for i, sub := range f {
+			or[i] = negate(sub)
+		}
+		return or
+	case Or:
+		and := make(And, len(f))
+		for i, sub := range f {
+			and[i] = negate(sub)
+		}
+		return and
+	case Not:
+		return f.Filter
+	default:
+		return Not{f}
+	}
+}
+
+// nullableFields return the fields whose null values are matched by neither the filter f, nor its
+// negation : the fields of the filters other than isnull, the keys of a Seek and the fields of
+// a Search.
+func nullableFields(f Filter) []string {
+	switch f := f.(type) {
+	case Search:
+		return f.Fields
+	case Seek:
+		fields := make([]string, len(f.Keys))
+		for i, key := range f.Keys {
+			fields[i] = key.Field
+		}
+		return fields
+	}
+	param, op, _, ok := filterOp(f)
+	if !ok || op == "isnull" {
+		return nil
+	}
+	return []string{param}
+}
+
+// joinSQL joins the conditions of filters with the operator op. The result is
+// always parenthesised so it can be nested in any other condition.
+func joinSQL(b *SQLBuilder, fs []Filter, op string, empty string) string {
+	if len(fs) == 0 {
+		return empty
+	}
+	conds := make([]string, len(fs))
+	for i, f := range fs {
+		conds[i] = f.BuildSQL(b)
+	}
+	return "(" + strings.Join(conds, " "+op+" ") + ")"
+}
+
+const (
+	groupAND = "and"
+	groupOR  = "or"
+	groupNOT = "not"
+)
+
+// filterGroup is used to build a tree of filters from url values.
+type filterGroup struct {
+	kind     string
+	negate   bool
+	children []any // Filter or *filterGroup
+	groups   map[string]*filterGroup
+}
+
+func newFilterGroup(kind string, negate bool) *filterGroup {
+	return &filterGroup{kind: kind, negate: negate, groups: map[string]*filterGroup{}}
+}
+
+// group return the sub group identified by kind and label, creating it if needed.
+func (g *filterGroup) group(kind, label string, negate bool) *filterGroup {
+	key := fmt.Sprintf("%v:%v:%v", negate, kind, label)
+	sub, ok := g.groups[key]
+	if !ok {
+		sub = newFilterGroup(kind, negate)
+		g.groups[key] = sub
+		g.children = append(g.children, sub)
+	}
+	return sub
+}
+
+// add a filter path to the group. The path is a list of segments : zero or more
+// modifiers ('not', 'and' <label>, 'or' <label>) followed by a field and an operator.
+func (g *filterGroup) add(path []string, value string) error {
+	negate := false
+	cur := g
+	for len(path) > 2 {
+		switch path[0] {
+		case groupNOT:
+			negate = !negate
+			path = path[1:]
+		case groupAND, groupOR:
+			if len(path) < 4 {
+				return errors.New("invalid filter")
+			}
+			cur = cur.group(path[0], path[1], negate)
+			negate = false
+			path = path[2:]
+		default:
+			return errors.New("invalid filter")
+		}
+	}
+	if len(path) != 2 {
+		return errors.New("invalid filter")
+	}
+	f, err := newFilter(path[0], path[1], value)
+	if err != nil {
+		return err
+	}
+	if negate {
+		f = Not{f}
+	}
+	cur.children = append(cur.children, f)
+	return nil
+}
+
+// filters return the filters of the group, sub groups being converted to composite filters.
+func (g *filterGroup) filters() Filters {
+	fs := make(Filters, 0, len(g.children))
+	for _, child := range g.children {
+		switch c := child.(type) {
+		case Filter:
+			fs = append(fs, c)
+		case *filterGroup:
+			fs = append(fs, c.filter())
+		}
+	}
+	return fs
+}
+
+func (g *filterGroup) filter() Filter {
+	var f Filter = And(g.filters())
+	if g.kind == groupOR {
+		f = Or(g.filters())
+	}
+	if g.negate {
+		f = Not{f}
+	}
+	return f
+}
@@ -0,0 +1,143 @@
+package alfred
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogicFromURLValues(t *testing.T) {
+	tests := map[string]struct {
+		url  string
+		want Filters
+	}{
+		"not": {
+			url:  "?filter[not][status][eq]=archived",
+			want: Filters{Not{EQ{"status", "archived"}}},
+		},
+		"or": {
+			url:  "?filter[or][g1][name][like]=bat&filter[or][g1][name][like]=super",
+			want: Filters{Or{Like{"name", "bat"}, Like{"name", "super"}}},
+		},
+		"or-and-filter": {
+			url:  "?filter[age][gt]=18&filter[or][g1][name][like]=bat&filter[or][g1][team][eq]=jla",
+			want: Filters{GT{"age", "18"}, Or{Like{"name", "bat"}, EQ{"team", "jla"}}},
+		},
+		"nested": {
+			url: "?filter[or][g1][and][g2][age][gt]=18&filter[or][g1][and][g2][age][lt]=30&filter[or][g1][name][eq]=bruce",
+			want: Filters{Or{
+				And{GT{"age", "18"}, LT{"age", "30"}},
+				EQ{"name", "bruce"},
+			}},
+		},
+		"negated-group": {
+			url:  "?filter[not][or][g1][name][eq]=bruce&filter[not][or][g1][name][eq]=clark",
+			want: Filters{Not{Or{EQ{"name", "bruce"}, EQ{"name", "clark"}}}},
+		},
+		"invalid": {
+			url:  "?filter[or][name][eq]=bruce&filter[xor][g1][name][eq]=bruce&filter[name][unknown]=bruce",
+			want: nil,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			url, err := url.Parse(tt.url)
+			assert.NoError(t, err)
+			f := ParseURLValues(url.Query())
+			assert.Equal(t, tt.want, f.Filters)
+		})
+	}
+}
+
+func TestLogicToSQL(t *testing.T) {
+	tests := map[string]struct {
+		filter Filter
+		want   string
+	}{
+		"and":       {filter: And{EQ{"name", "bruce"}, GT{"age", "18"}}, want: `("name" = 'bruce' AND "age" > '18')`},
+		"or":        {filter: Or{EQ{"name", "bruce"}, GT{"age", "18"}}, want: `("name" = 'bruce' OR "age" > '18')`},
+		"not":       {filter: Not{EQ{"name", "bruce"}}, want: `NOT ("name" = 'bruce')`},
+		"and-empty": {filter: And{}, want: `TRUE`},
+		"or-empty":  {filter: Or{}, want: `FALSE`},
+		"nested": {
+			filter: Not{Or{And{GT{"age", "18"}, LT{"age", "30"}}, EQ{"name", "bruce"}}},
+			want:   `NOT ((("age" > '18' AND "age" < '30') OR "name" = 'bruce'))`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.ToSQL())
+		})
+	}
+}
+
+func TestLogicKeep(t *testing.T) {
+	data := struct {
+		Name string `filter:"name"`
+		Age  int    `filter:"age"`
+	}{
+		Name: "Bruce",
+		Age:  39,
+	}
+	tests := map[string]struct {
+		filter Filter
+		want   bool
+	}{
+		"and-keep": {filter: And{Like{"name", "bru"}, GT{"age", "18"}}, want: true},
+		"and-drop": {filter: And{Like{"name", "bru"}, GT{"age", "42"}}, want: false},
+		"or-keep":  {filter: Or{Like{"name", "diana"}, GT{"age", "18"}}, want: true},
+		"or-drop":  {filter: Or{Like{"name", "diana"}, GT{"age", "42"}}, want: false},
+		"or-empty": {filter: Or{}, want: false},
+		"not-keep": {filter: Not{Like{"name", "diana"}}, want: true},
+		"not-drop": {filter: Not{Like{"name", "bruce"}}, want: false},
+		"nested":   {filter: Not{Or{And{GT{"age", "18"}, LT{"age", "30"}}, EQ{"name", "Diana"}}}, want: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := tt.filter.Keep(data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, actual)
+		})
+	}
+}
+
+func TestNotKeepNull(t *testing.T) {
+	type nullHero struct {
+		Name string  `filter:"name"`
+		Team *string `filter:"team"`
+	}
+	jla := "jla"
+	tests := map[string]struct {
+		data   nullHero
+		filter Filter
+		want   bool
+	}{
+		"not":        {data: nullHero{Name: "Bruce"}, filter: Not{EQ{"team", "jsa"}}, want: false},
+		"not-value":  {data: nullHero{Name: "Bruce", Team: &jla}, filter: Not{EQ{"team", "jsa"}}, want: true},
+		"not-isnull": {data: nullHero{Name: "Bruce"}, filter: Not{IsNull{"team", "false"}}, want: true},
+		"not-and":    {data: nullHero{Name: "Bruce"}, filter: Not{And{EQ{"name", "Bruce"}, EQ{"team", "jla"}}}, want: false},
+		"not-or":     {data: nullHero{Name: "Bruce"}, filter: Not{Or{EQ{"name", "Clark"}, EQ{"team", "jla"}}}, want: false},
+		"not-not":    {data: nullHero{Name: "Bruce"}, filter: Not{Not{EQ{"team", "jla"}}}, want: false},
+		"not-empty":  {data: nullHero{Name: "Bruce"}, filter: Not{And{}}, want: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			keep, err := tt.filter.Keep(tt.data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, keep)
+
+			c, err := Filters{tt.filter}.Compile(tt.data)
+			assert.NoError(t, err)
+			keep, err = c.Keep(tt.data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, keep)
+		})
+	}
+}
+
+func TestLogicAddToPSQLQuery(t *testing.T) {
+	opt := Option{Filters: Filters{GT{"age", "18"}, Or{Like{"name", "bat"}, Like{"name", "super"}}}}
+	want := `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE "age" > '18' AND ("name" ILIKE '%bat%' ESCAPE '\' OR "name" ILIKE '%super%' ESCAPE '\') LIMIT ALL OFFSET 0`
+	assert.Equal(t, want, AddToPSQLQuery("SELECT * FROM heroes", opt))
+}
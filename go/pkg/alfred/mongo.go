@@ -0,0 +1,237 @@
+package alfred
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MongoBuilder builds MongoDB queries.
+type MongoBuilder struct {
+	// Schema of the documents, typing the values of the filters as their fields, giving the
+	// searchable fields of a Search filter without fields and the names of the fields in the
+	// documents (the column option of their tag). Filters not allowed by the schema, and the
+	// groups holding them, match nothing, and sort keys not allowed are left out (see NewSchema).
+	// The schema of an option parsed by Schema.ParseURLValues is used if nil. Without schema,
+	// values are strings, as their type cannot be told from their text ('12345' may be a zip code).
+	Schema *Schema
+}
+
+// MongoQuery is a MongoDB find query, to be run with collection.Find(ctx, q.Filter, options.Find().
+// SetSort(q.Sort).SetSkip(q.Skip).SetLimit(q.Limit)).
+type MongoQuery struct {
+	Filter map[string]any
+	// Sort is the sort document, ordered by the sort keys, nil without sort keys.
+	Sort bson.D
+	Skip int64
+	// Limit is the maximum number of documents, all of them if 0.
+	Limit int64
+	// Projection is the projection document of the fields of the option, nil without fields.
+	Projection map[string]any
+	// Collation is the locale of the collation of the option, see options.Collation.
+	Collation string
+}
+
+// Document return the query as the fields of a find command : filter, sort, skip, limit,
+// projection and collation. Empty fields are left out.
+func (q MongoQuery) Document() map[string]any {
+	doc := map[string]any{"filter": q.Filter}
+	if len(q.Sort) > 0 {
+		doc["sort"] = q.Sort
+	}
+	if q.Skip > 0 {
+		doc["skip"] = q.Skip
+	}
+	if q.Limit > 0 {
+		doc["limit"] = q.Limit
+	}
+	if len(q.Projection) > 0 {
+		doc["projection"] = q.Projection
+	}
+	if q.Collation != "" {
+		doc["collation"] = map[string]any{"locale": q.Collation}
+	}
+	return doc
+}
+
+// BuildMongoQuery return the MongoDB query of the option, see MongoBuilder.Query.
+func BuildMongoQuery(opt Option) (MongoQuery, error) {
+	return (&MongoBuilder{}).Query(opt)
+}
+
+// Query return the MongoDB query filtered, sorted and paginated according to opt, with the same
+// semantics as the SQL query of opt. MongoDB orders null and missing values first, the Nulls
+// of opt is not supported.
+func (b *MongoBuilder) Query(opt Option) (MongoQuery, error) {
+	s := newStoreSchema(b.Schema, opt)
+	filter, err := mongoFilter(s, And(s.filters(opt)))
+	if err != nil {
+		return MongoQuery{}, err
+	}
+	q := MongoQuery{Filter: filter, Skip: int64(max(opt.Offset, 0)), Limit: int64(max(opt.Limit, 0)), Collation: opt.Collation}
+	for _, key := range s.sortKeys(opt) {
+		order := 1
+		if key.Desc {
+			order = -1
+		}
+		q.Sort = append(q.Sort, bson.E{Key: s.field(key.Field), Value: order})
+	}
+	if len(opt.Fields) > 0 {
+		q.Projection = map[string]any{}
+		for _, field := range opt.Fields {
+			q.Projection[s.field(field)] = 1
+		}
+	}
+	return q, nil
+}
+
+// Filter return the MongoDB filter document of the filter f.
+func (b *MongoBuilder) Filter(f Filter) (map[string]any, error) {
+	return mongoFilter(newStoreSchema(b.Schema, Option{}), f)
+}
+
+// mongoFalse is a filter document matching no document.
+var mongoFalse = map[string]any{"$nor": []any{map[string]any{}}}
+
+func mongoFilter(s storeSchema, f Filter) (map[string]any, error) {
+	group := func(fs []Filter) ([]any, error) {
+		docs := make([]any, len(fs))
+		for i, sub := range fs {
+			doc, err := mongoFilter(s, sub)
+			if err != nil {
+				return nil, err
+			}
+			docs[i] = doc
+		}
+		return docs, nil
+	}
+
+	switch f := f.(type) {
+	case And:
+		if len(f) == 1 {
+			return mongoFilter(s, f[0])
+		}
+		docs, err := group(f)
+		if err != nil || len(docs) == 0 {
+			return map[string]any{}, err
+		}
+		return map[string]any{"$and": docs}, nil
+	case Or:
+		if len(f) == 1 {
+			return mongoFilter(s, f[0])
+		}
+		docs, err := group(f)
+		if err != nil || len(docs) == 0 {
+			return mongoFalse, err
+		}
+		return map[string]any{"$or": docs}, nil
+	case Not:
+		not, ok := negate(f.Filter).(Not)
+		if !ok {
+			return mongoFilter(s, negate(f.Filter))
+		}
+		doc, err := mongoFilter(s, not.Filter)
+		if err != nil {
+			return nil, err
+		}
+		// $nor matches the null values, not matched in SQL
+		var and []any
+		for _, field := range s.nullable(not.Filter) {
+			and = append(and, map[string]any{s.field(field): map[string]any{"$ne": nil}})
+		}
+		if len(and) == 0 {
+			return map[string]any{"$nor": []any{doc}}, nil
+		}
+		return map[string]any{"$and": append(and, map[string]any{"$nor": []any{doc}})}, nil
+	case Seek:
+		// MongoDB orders null values lower than any other value, whatever the Nulls of the option
+		f.Nulls = ""
+		return mongoFilter(s, f.expand())
+	case Search:
+		var or []any
+		for _, field := range s.searchFields(f) {
+			or = append(or, map[string]any{s.field(field): mongoRegex(regexp.QuoteMeta(f.Value), true)})
+		}
+		if len(or) == 0 {
+			return nil, errNoSearchFields
+		}
+		return map[string]any{"$or": or}, nil
+	}
+
+	param, op, value, ok := filterOp(f)
+	if !ok {
+		return nil, fmt.Errorf("filter : unsupported filter type (%T)", f)
+	}
+	field := s.field(param)
+	condition := func(cond any) map[string]any { return map[string]any{field: cond} }
+	var values []any
+	switch op {
+	case "like", "startswith", "regex", "isnull":
+		// the values are not values of the field
+	default:
+		var err error
+		if values, err = s.values(param, filterValues(f)); err != nil {
+			return nil, err
+		}
+	}
+
+	switch op {
+	case "eq", "gt", "gte", "lt", "lte":
+		return condition(map[string]any{"$" + op: values[0]}), nil
+	case "in":
+		return condition(map[string]any{"$in": values}), nil
+	case "ne", "nin":
+		// $ne and $nin match the null and missing values, not matched in SQL
+		return condition(map[string]any{"$nin": append(values, nil)}), nil
+	case "between":
+		return condition(map[string]any{"$gte": values[0], "$lte": values[1]}), nil
+	case "like":
+		return condition(mongoRegex(regexp.QuoteMeta(value), true)), nil
+	case "startswith":
+		return condition(mongoRegex("^"+regexp.QuoteMeta(value), true)), nil
+	case "regex":
+		return condition(mongoRegex(value, false)), nil
+	case "isnull":
+		null, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, ErrParamType{param, err}
+		}
+		if null {
+			return condition(map[string]any{"$eq": nil}), nil
+		}
+		return condition(map[string]any{"$ne": nil}), nil
+	}
+
+	// list operators
+	if s.isString(param) {
+		var docs []any
+		for _, v := range filterValues(f) {
+			docs = append(docs, condition(mongoRegex("(^|,)"+regexp.QuoteMeta(v)+"(,|$)", false)))
+		}
+		if op == "overlaps" {
+			return map[string]any{"$or": docs}, nil
+		}
+		if len(docs) == 1 {
+			return docs[0].(map[string]any), nil
+		}
+		return map[string]any{"$and": docs}, nil
+	}
+	switch op {
+	case "contain":
+		return condition(map[string]any{"$eq": values[0]}), nil
+	case "containsAll":
+		return condition(map[string]any{"$all": values}), nil
+	default:
+		return condition(map[string]any{"$in": values}), nil
+	}
+}
+
+// mongoRegex return the $regex condition of the pattern, case insensitive with i.
+func mongoRegex(pattern string, i bool) map[string]any {
+	if i {
+		return map[string]any{"$regex": pattern, "$options": "i"}
+	}
+	return map[string]any{"$regex": pattern}
+}
@@ -0,0 +1,178 @@
+package alfred
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type m = map[string]any
+
+// customFilter is a filter unknown to the builders.
+type customFilter struct{}
+
+func (customFilter) Keep(v any) (bool, error)      { return true, nil }
+func (customFilter) ToSQL() string                 { return "TRUE" }
+func (customFilter) BuildSQL(b *SQLBuilder) string { return "TRUE" }
+
+func TestMongoFilter(t *testing.T) {
+	b := &MongoBuilder{Schema: NewSchema(storeHero{})}
+	birth := time.Date(1939, time.May, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		filter Filter
+		want   map[string]any
+	}{
+		"eq":           {filter: EQ{"name", "42"}, want: m{"name": m{"$eq": "42"}}},
+		"ne":           {filter: NE{"age", "42"}, want: m{"age": m{"$nin": []any{int64(42), nil}}}},
+		"gt":           {filter: GT{"score", "1.5"}, want: m{"score": m{"$gt": 1.5}}},
+		"gte":          {filter: GTE{"birth", "1939-05-01"}, want: m{"birth": m{"$gte": birth}}},
+		"lt":           {filter: LT{"cooldown", "1h"}, want: m{"cooldown": m{"$lt": time.Hour}}},
+		"lte":          {filter: LTE{"age", "40"}, want: m{"age": m{"$lte": int64(40)}}},
+		"bool":         {filter: EQ{"alive", "true"}, want: m{"alive": m{"$eq": true}}},
+		"in":           {filter: In{"age", []string{"1", "2"}}, want: m{"age": m{"$in": []any{int64(1), int64(2)}}}},
+		"nin":          {filter: NotIn{"name", []string{"a", "b"}}, want: m{"name": m{"$nin": []any{"a", "b", nil}}}},
+		"between":      {filter: Between{"age", "18", "30"}, want: m{"age": m{"$gte": int64(18), "$lte": int64(30)}}},
+		"like":         {filter: Like{"name", "b.t"}, want: m{"name": m{"$regex": `b\.t`, "$options": "i"}}},
+		"startswith":   {filter: StartsWith{"name", "Bat"}, want: m{"name": m{"$regex": "^Bat", "$options": "i"}}},
+		"regex":        {filter: Regex{"name", "^B.*n$"}, want: m{"name": m{"$regex": "^B.*n$"}}},
+		"isnull":       {filter: IsNull{"age", "true"}, want: m{"age": m{"$eq": nil}}},
+		"not-null":     {filter: IsNull{"age", "false"}, want: m{"age": m{"$ne": nil}}},
+		"contain":      {filter: Contain{"tags", "rich"}, want: m{"tags": m{"$eq": "rich"}}},
+		"contains-all": {filter: ContainsAll{"ranks", []string{"1", "2"}}, want: m{"ranks": m{"$all": []any{uint64(1), uint64(2)}}}},
+		"overlaps":     {filter: Overlaps{"tags", []string{"a", "b"}}, want: m{"tags": m{"$in": []any{"a", "b"}}}},
+		"list-string":  {filter: Contain{"powers", "fly"}, want: m{"powers": m{"$regex": "(^|,)fly(,|$)"}}},
+		"list-string-all": {
+			filter: ContainsAll{"powers", []string{"fly", "x-ray"}},
+			want:   m{"$and": []any{m{"powers": m{"$regex": "(^|,)fly(,|$)"}}, m{"powers": m{"$regex": "(^|,)x-ray(,|$)"}}}},
+		},
+		"list-string-any": {
+			filter: Overlaps{"powers", []string{"fly"}},
+			want:   m{"$or": []any{m{"powers": m{"$regex": "(^|,)fly(,|$)"}}}},
+		},
+		"column": {filter: EQ{"team", "jla"}, want: m{"team_name": m{"$eq": "jla"}}},
+		"nested": {filter: EQ{"address.city", "Gotham"}, want: m{"address.town": m{"$eq": "Gotham"}}},
+		"and": {
+			filter: And{EQ{"name", "Bruce"}, GT{"age", "18"}},
+			want:   m{"$and": []any{m{"name": m{"$eq": "Bruce"}}, m{"age": m{"$gt": int64(18)}}}},
+		},
+		"and-one":  {filter: And{EQ{"name", "Bruce"}}, want: m{"name": m{"$eq": "Bruce"}}},
+		"and-none": {filter: And{}, want: m{}},
+		"or": {
+			filter: Or{EQ{"name", "Bruce"}, Not{EQ{"alive", "true"}}},
+			want: m{"$or": []any{
+				m{"name": m{"$eq": "Bruce"}},
+				m{"$and": []any{m{"alive": m{"$ne": nil}}, m{"$nor": []any{m{"alive": m{"$eq": true}}}}}},
+			}},
+		},
+		"not-group": {
+			filter: Not{Or{EQ{"name", "Bruce"}, IsNull{"age", "true"}}},
+			want: m{"$and": []any{
+				m{"$and": []any{m{"name": m{"$ne": nil}}, m{"$nor": []any{m{"name": m{"$eq": "Bruce"}}}}}},
+				m{"$nor": []any{m{"age": m{"$eq": nil}}}},
+			}},
+		},
+		"not-not": {filter: Not{Not{EQ{"name", "Bruce"}}}, want: m{"name": m{"$eq": "Bruce"}}},
+		"or-none": {filter: Or{}, want: m{"$nor": []any{m{}}}},
+		"search":  {filter: Search{Value: "b.t"}, want: m{"$or": []any{m{"name": m{"$regex": `b\.t`, "$options": "i"}}}}},
+		"search-fields": {
+			filter: Search{Value: "bat", Fields: []string{"name", "address.city"}},
+			want:   m{"$or": []any{m{"name": m{"$regex": "bat", "$options": "i"}}, m{"address.town": m{"$regex": "bat", "$options": "i"}}}},
+		},
+		"seek": {
+			filter: Seek{Keys: []SortKey{{"age", true}, {"name", false}}, Values: []string{"40", "Bruce"}},
+			want: m{"$or": []any{
+				m{"$or": []any{m{"age": m{"$lt": int64(40)}}, m{"age": m{"$eq": nil}}}},
+				m{"$and": []any{m{"age": m{"$eq": int64(40)}}, m{"name": m{"$gt": "Bruce"}}}},
+			}},
+		},
+		"seek-nulls": {
+			filter: Seek{Keys: []SortKey{{"age", false}}, Values: []string{"40"}, Nulls: "last"},
+			want:   m{"age": m{"$gt": int64(40)}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := b.Filter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, doc)
+		})
+	}
+}
+
+func TestMongoFilterErrors(t *testing.T) {
+	b := &MongoBuilder{Schema: NewSchema(storeHero{})}
+	_, err := b.Filter(Or{EQ{"name", "Bruce"}, GT{"age", "old"}})
+	assert.EqualError(t, err, `filter: field 'age' : strconv.ParseInt: parsing "old": invalid syntax`)
+	_, err = b.Filter(IsNull{"age", "maybe"})
+	assert.Error(t, err)
+	_, err = b.Filter(customFilter{})
+	assert.EqualError(t, err, "filter : unsupported filter type (alfred.customFilter)")
+}
+
+func TestBuildMongoQuery(t *testing.T) {
+	q, err := BuildMongoQuery(Option{
+		Limit:     10,
+		Offset:    20,
+		SortBy:    "team,-age",
+		Collation: "fr",
+		Fields:    []string{"name", "age"},
+		Filters:   Filters{Like{"name", "bat"}, GT{"age", "18"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, MongoQuery{
+		Filter:     m{"$and": []any{m{"name": m{"$regex": "bat", "$options": "i"}}, m{"age": m{"$gt": "18"}}}},
+		Sort:       bson.D{{Key: "team", Value: 1}, {Key: "age", Value: -1}},
+		Skip:       20,
+		Limit:      10,
+		Projection: m{"name": 1, "age": 1},
+		Collation:  "fr",
+	}, q)
+	assert.Equal(t, m{
+		"filter":     q.Filter,
+		"sort":       q.Sort,
+		"skip":       int64(20),
+		"limit":      int64(10),
+		"projection": q.Projection,
+		"collation":  m{"locale": "fr"},
+	}, q.Document())
+
+	q, err = BuildMongoQuery(Option{Limit: -1, Offset: -1})
+	require.NoError(t, err)
+	assert.Equal(t, MongoQuery{Filter: m{}}, q)
+	assert.Equal(t, m{"filter": m{}}, q.Document())
+
+	// without schema, values are strings and searches have no searchable fields
+	q, err = BuildMongoQuery(Option{Filters: Filters{EQ{"zip", "12345"}}})
+	require.NoError(t, err)
+	assert.Equal(t, m{"zip": m{"$eq": "12345"}}, q.Filter)
+	_, err = BuildMongoQuery(Option{Filters: Filters{Search{Value: "bat"}}})
+	assert.ErrorIs(t, err, errNoSearchFields)
+}
+
+func TestMongoQuerySchema(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	opt := NewSchema(storeHero{}).ParseURLValues(map[string][]string{
+		"filter[team][like]":   {"jl"},
+		"filter[team][eq]":     {"jla"},
+		"filter[birth][gte]":   {"today-1d"},
+		"sortBy":               {"-address.city,score"},
+		"limit":                {"5"},
+		"cursor":               {encodeCursor([]string{"Gotham"})},
+		"filter[address.city]": {"invalid"},
+	})
+	opt.Now = now
+	q, err := (&MongoBuilder{}).Query(opt)
+	require.NoError(t, err)
+	assert.Equal(t, MongoQuery{
+		Filter: m{"$and": []any{
+			m{"birth": m{"$gte": time.Date(2024, time.May, 31, 0, 0, 0, 0, time.UTC)}},
+			m{"team_name": m{"$eq": "jla"}},
+			m{"$or": []any{m{"address.town": m{"$lt": "Gotham"}}, m{"address.town": m{"$eq": nil}}}},
+		}},
+		Sort:  bson.D{{Key: "address.town", Value: -1}},
+		Limit: 5,
+	}, q)
+}
@@ -0,0 +1,448 @@
+package alfred
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OData is the Parser of the OData system query options : '$filter', '$orderby=age desc,name',
+// '$top', '$skip', '$select=id,name' and '$search'. See ParseODataFilter for the '$filter' syntax.
+type OData struct{}
+
+// Parse the raw query as OData system query options.
+func (OData) Parse(rawQuery string) Option {
+	values, _ := url.ParseQuery(rawQuery)
+	opt := Option{}
+
+	integer := func(key string) int {
+		s := values.Get(key)
+		n, err := strconv.Atoi(s)
+		if err != nil && s != "" {
+			opt.errs = append(opt.errs, ErrInvalidParam{Param: key, Value: s, Reason: "invalid integer"})
+		}
+		return n
+	}
+	opt.Limit, opt.Offset = integer("$top"), integer("$skip")
+
+	var sortBy []string
+	for _, item := range strings.Split(values.Get("$orderby"), ",") {
+		words := strings.Fields(item)
+		switch {
+		case len(words) == 0:
+		case len(words) == 1 || len(words) == 2 && strings.EqualFold(words[1], "asc"):
+			sortBy = append(sortBy, odataPath(words[0]))
+		case len(words) == 2 && strings.EqualFold(words[1], "desc"):
+			sortBy = append(sortBy, "-"+odataPath(words[0]))
+		default:
+			opt.errs = append(opt.errs, ErrInvalidParam{Param: "$orderby", Value: item, Reason: "invalid order, must be '<field> [asc|desc]'"})
+		}
+	}
+	opt.SortBy = strings.Join(sortBy, ",")
+
+	for _, field := range strings.Split(values.Get("$select"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			opt.Fields = append(opt.Fields, odataPath(field))
+		}
+	}
+
+	if expr := values.Get("$filter"); expr != "" {
+		fs, err := ParseODataFilter(expr)
+		if err != nil {
+			opt.errs = append(opt.errs, ErrInvalidParam{Param: "$filter", Value: expr, Reason: err.Error()})
+		}
+		opt.Filters = fs
+	}
+	if search := values.Get("$search"); search != "" {
+		opt.Filters = append(opt.Filters, Search{Value: search})
+	}
+	return opt
+}
+
+// odataPath return the path of an OData property path ('address/city' is 'address.city').
+func odataPath(s string) string {
+	return strings.ReplaceAll(s, "/", ".")
+}
+
+// Encode return the option as OData system query options. The first Search filter without fields is
+// the '$search' option. Filters which ParseODataFilter cannot return, as the list operators, cannot be
+// encoded, nor can collations, null orderings and cursors.
+func (OData) Encode(rawQuery string, opt Option) (string, error) {
+	if err := checkEncodable(opt, "OData"); err != nil {
+		return "", err
+	}
+	query, _ := url.ParseQuery(rawQuery)
+	for _, key := range []string{"$filter", "$orderby", "$top", "$skip", "$select", "$search"} {
+		query.Del(key)
+	}
+	if opt.Limit != 0 {
+		query.Set("$top", strconv.Itoa(opt.Limit))
+	}
+	if opt.Offset != 0 {
+		query.Set("$skip", strconv.Itoa(opt.Offset))
+	}
+	var orderBy []string
+	for _, key := range opt.SortKeys() {
+		property, err := odataProperty(key.Field)
+		if err != nil {
+			return "", err
+		}
+		if key.Desc {
+			property += " desc"
+		}
+		orderBy = append(orderBy, property)
+	}
+	if len(orderBy) > 0 {
+		query.Set("$orderby", strings.Join(orderBy, ","))
+	}
+	var selects []string
+	for _, field := range opt.Fields {
+		property, err := odataProperty(field)
+		if err != nil {
+			return "", err
+		}
+		selects = append(selects, property)
+	}
+	if len(selects) > 0 {
+		query.Set("$select", strings.Join(selects, ","))
+	}
+
+	var exprs []string
+	for _, f := range opt.Filters {
+		if search, ok := f.(Search); ok && len(search.Fields) == 0 && search.Value != "" && !query.Has("$search") {
+			query.Set("$search", search.Value)
+			continue
+		}
+		if and, ok := f.(And); ok && len(and) == 0 {
+			// keeps everything, as no filter
+			continue
+		}
+		expr, err := odataFilter(f)
+		if err != nil {
+			return "", err
+		}
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) > 0 {
+		query.Set("$filter", strings.Join(exprs, " and "))
+	}
+	return query.Encode(), nil
+}
+
+// odataProperty return the OData property path of the field path ('address.city' is 'address/city').
+func odataProperty(path string) (string, error) {
+	if path == "" || strings.ContainsAny(path, " \t\n\r(),'/") {
+		return "", errNotEncodableParam(fmt.Sprintf("field %q", path), "OData")
+	}
+	return strings.ReplaceAll(path, ".", "/"), nil
+}
+
+// odataString return the OData string literal s : 's', quotes being doubled.
+func odataString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// odataFilter return the '$filter' expression of the filter f, the inverse of ParseODataFilter.
+// Groups are parenthesized.
+func odataFilter(f Filter) (string, error) {
+	group := func(fs []Filter, op string) (string, error) {
+		if len(fs) == 0 {
+			return "", errNotEncodable(f, "empty group")
+		}
+		exprs := make([]string, len(fs))
+		for i, sub := range fs {
+			expr, err := odataFilter(sub)
+			if err != nil {
+				return "", err
+			}
+			exprs[i] = expr
+		}
+		return "(" + strings.Join(exprs, " "+op+" ") + ")", nil
+	}
+	switch f := f.(type) {
+	case And:
+		return group(f, "and")
+	case Or:
+		return group(f, "or")
+	case Not:
+		expr, err := odataFilter(f.Filter)
+		if err != nil {
+			return "", err
+		}
+		return "not (" + expr + ")", nil
+	}
+
+	param, op, value, ok := filterOp(f)
+	if !ok {
+		return "", errNotEncodable(f, "unsupported filter type")
+	}
+	property, err := odataProperty(param)
+	if err != nil {
+		return "", err
+	}
+	list := func() string {
+		values := make([]string, len(filterValues(f)))
+		for i, v := range filterValues(f) {
+			values[i] = odataString(v)
+		}
+		return property + " in (" + strings.Join(values, ", ") + ")"
+	}
+	switch op {
+	case "eq", "ne", "gt", "lt":
+		return property + " " + op + " " + odataString(value), nil
+	case "gte", "lte":
+		return property + " " + op[:1] + "e " + odataString(value), nil
+	case "in":
+		if len(filterValues(f)) > 0 {
+			return list(), nil
+		}
+	case "nin":
+		if len(filterValues(f)) > 0 {
+			return "not (" + list() + ")", nil
+		}
+	case "between":
+		from, to := filterValues(f)[0], filterValues(f)[1]
+		return "(" + property + " ge " + odataString(from) + " and " + property + " le " + odataString(to) + ")", nil
+	case "isnull":
+		null, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", ErrParamType{param, err}
+		}
+		if null {
+			return property + " eq null", nil
+		}
+		return property + " ne null", nil
+	case "like":
+		return "contains(" + property + ", " + odataString(value) + ")", nil
+	case "startswith":
+		return "startswith(" + property + ", " + odataString(value) + ")", nil
+	case "regex":
+		// the regular expressions of endswith : (?i)<text>$
+		pattern, insensitive := strings.CutPrefix(value, "(?i)")
+		if text, ok := regexLiteral(strings.TrimSuffix(pattern, "$")); ok && insensitive && strings.HasSuffix(pattern, "$") {
+			return "endswith(" + property + ", " + odataString(text) + ")", nil
+		}
+	}
+	return "", errNotEncodable(f, "operator not supported by OData")
+}
+
+// regexLiteral return the text matched by the regular expression s, and true if s is the quoted
+// text (see regexp.QuoteMeta).
+func regexLiteral(s string) (string, bool) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	text := sb.String()
+	return text, regexp.QuoteMeta(text) == s
+}
+
+// ParseODataFilter parse the filters of an OData '$filter' expression ('age gt 18 and name eq 'Bruce'').
+// The comparison operators are eq, ne, gt, ge, lt, le, and in ('team in ('jla', 'jsa')'), 'eq null' and
+// 'ne null' testing null values. The functions are contains, startswith and endswith ('contains(name, 'bat')').
+// Expressions are combined with and, or, not and parentheses. Property paths use '/' ('address/city').
+// It returns a *SyntaxError if the expression is invalid.
+func ParseODataFilter(expr string) (Filters, error) {
+	tokens, err := lex(expr, true)
+	if err != nil {
+		return nil, err
+	}
+	p := odataParser{tokenReader{tokens: tokens}}
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.next(); t.kind != tokenEOF {
+		return nil, errorAt(t, "unexpected %v", t)
+	}
+	if and, ok := f.(And); ok {
+		return Filters(and), nil
+	}
+	return Filters{f}, nil
+}
+
+type odataParser struct {
+	tokenReader
+}
+
+// keyword return true if the next token is the keyword, case insensitive, and read it.
+func (p *odataParser) keyword(keyword string) bool {
+	if t := p.peek(); t.kind == tokenWord && strings.EqualFold(t.text, keyword) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// or := and ('or' and)*
+func (p *odataParser) or() (Filter, error) {
+	f, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	or := Or{f}
+	for p.keyword("or") {
+		if f, err = p.and(); err != nil {
+			return nil, err
+		}
+		or = append(or, f)
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+// and := unary ('and' unary)*
+func (p *odataParser) and() (Filter, error) {
+	f, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	and := And{f}
+	for p.keyword("and") {
+		if f, err = p.unary(); err != nil {
+			return nil, err
+		}
+		and = append(and, f)
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+// unary := 'not' unary | '(' or ')' | function | comparison
+func (p *odataParser) unary() (Filter, error) {
+	if p.keyword("not") {
+		f, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{f}, nil
+	}
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		f, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case tokenWord:
+		if p.peek().kind == tokenLParen {
+			return p.function(t)
+		}
+		return p.comparison(t)
+	default:
+		return nil, errorAt(t, "expected expression, got %v", t)
+	}
+}
+
+// function := name '(' property ',' value ')'
+func (p *odataParser) function(name token) (Filter, error) {
+	p.next()
+	field, err := p.expect(tokenWord, "property")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokenComma, "','"); err != nil {
+		return nil, err
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokenRParen, "')'"); err != nil {
+		return nil, err
+	}
+	param := odataPath(field.text)
+	switch strings.ToLower(name.text) {
+	case "contains":
+		return Like{param, value.text}, nil
+	case "startswith":
+		return StartsWith{param, value.text}, nil
+	case "endswith":
+		// case insensitive, as contains and startswith
+		return Regex{param, "(?i)" + regexp.QuoteMeta(value.text) + "$"}, nil
+	default:
+		return nil, errorAt(name, "unknown function %v", name)
+	}
+}
+
+// comparison := property op value | property 'in' '(' value (',' value)* ')'
+func (p *odataParser) comparison(field token) (Filter, error) {
+	param := odataPath(field.text)
+	op, err := p.expect(tokenWord, "operator")
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(op.text) {
+	case "eq", "ne", "gt", "ge", "lt", "le", "in":
+	default:
+		return nil, errorAt(op, "unknown operator %v", op)
+	}
+	if strings.EqualFold(op.text, "in") {
+		if _, err := p.expect(tokenLParen, "'('"); err != nil {
+			return nil, err
+		}
+		var values []string
+		for {
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value.text)
+			if t := p.next(); t.kind == tokenRParen {
+				return In{param, values}, nil
+			} else if t.kind != tokenComma {
+				return nil, errorAt(t, "expected ',' or ')', got %v", t)
+			}
+		}
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if value.kind == tokenWord && value.text == "null" {
+		switch strings.ToLower(op.text) {
+		case "eq":
+			return IsNull{param, "true"}, nil
+		case "ne":
+			return IsNull{param, "false"}, nil
+		default:
+			return nil, errorAt(value, "null only compares with eq and ne")
+		}
+	}
+	switch strings.ToLower(op.text) {
+	case "eq":
+		return EQ{param, value.text}, nil
+	case "ne":
+		return NE{param, value.text}, nil
+	case "gt":
+		return GT{param, value.text}, nil
+	case "ge":
+		return GTE{param, value.text}, nil
+	case "lt":
+		return LT{param, value.text}, nil
+	default:
+		return LTE{param, value.text}, nil
+	}
+}
+
+// value := string | word
+func (p *odataParser) value() (token, error) {
+	t := p.next()
+	if t.kind != tokenWord && t.kind != tokenString {
+		return t, errorAt(t, "expected value, got %v", t)
+	}
+	return t, nil
+}
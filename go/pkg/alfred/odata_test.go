@@ -0,0 +1,152 @@
+package alfred
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseODataFilter(t *testing.T) {
+	tests := map[string]struct {
+		expr string
+		want Filters
+		err  string
+	}{
+		"comparison": {
+			expr: `age gt 18 and name eq 'Bruce'`,
+			want: Filters{GT{"age", "18"}, EQ{"name", "Bruce"}},
+		},
+		"operators": {
+			expr: `a ne 1 and b ge 2 and c lt -3 and d le 2006-05-01T00:00:00Z and e eq 'it''s'`,
+			want: Filters{NE{"a", "1"}, GTE{"b", "2"}, LT{"c", "-3"}, LTE{"d", "2006-05-01T00:00:00Z"}, EQ{"e", "it's"}},
+		},
+		"precedence": {
+			expr: `not age gt 18 or team eq 'jla' and address/city eq 'Gotham'`,
+			want: Filters{Or{Not{GT{"age", "18"}}, And{EQ{"team", "jla"}, EQ{"address.city", "Gotham"}}}},
+		},
+		"parentheses": {
+			expr: `NOT (age lt 18 OR age gt 30) and team in ('jla', 'jsa')`,
+			want: Filters{Not{Or{LT{"age", "18"}, GT{"age", "30"}}}, In{"team", []string{"jla", "jsa"}}},
+		},
+		"null": {
+			expr: `deletedAt eq null and name ne null and nick eq 'null'`,
+			want: Filters{IsNull{"deletedAt", "true"}, IsNull{"name", "false"}, EQ{"nick", "null"}},
+		},
+		"functions": {
+			expr: `contains(name, 'bat') or startswith(name,'Sup') or endswith(name, 'man.')`,
+			want: Filters{Or{Like{"name", "bat"}, StartsWith{"name", "Sup"}, Regex{"name", `(?i)man\.$`}}},
+		},
+		"unknown-operator": {expr: `age gte 18`, err: "syntax error at position 5 : unknown operator 'gte'"},
+		"unknown-function": {expr: `length(name, 3)`, err: "syntax error at position 1 : unknown function 'length'"},
+		"missing-value":    {expr: `age gt`, err: "syntax error at position 7 : expected value, got end of input"},
+		"missing-paren":    {expr: `(age gt 18`, err: "syntax error at position 11 : expected ')', got end of input"},
+		"trailing":         {expr: `age gt 18 18`, err: "syntax error at position 11 : unexpected '18'"},
+		"null-compare":     {expr: `age gt null`, err: "syntax error at position 8 : null only compares with eq and ne"},
+		"in-separator":     {expr: `team in ('jla' 'jsa')`, err: "syntax error at position 16 : expected ',' or ')', got 'jsa'"},
+		"expression":       {expr: `and age gt 1`, err: "syntax error at position 5 : unknown operator 'age'"},
+		"empty-group":      {expr: `()`, err: "syntax error at position 2 : expected expression, got ')'"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs, err := ParseODataFilter(tt.expr)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				var errSyntax *SyntaxError
+				assert.ErrorAs(t, err, &errSyntax)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, fs)
+		})
+	}
+
+	// endswith is case insensitive, as contains and startswith
+	fs, err := ParseODataFilter("endswith(name, 'MAN')")
+	require.NoError(t, err)
+	keep, err := fs.Keep(httpHero{Name: "Batman"})
+	require.NoError(t, err)
+	assert.True(t, keep)
+}
+
+func TestODataParse(t *testing.T) {
+	query := url.Values{
+		"$filter":  {"age ge 18 and contains(name, 'a')"},
+		"$orderby": {"team desc, address/city,name asc"},
+		"$top":     {"10"},
+		"$skip":    {"20"},
+		"$select":  {"id,address/city"},
+		"$search":  {"bat"},
+	}.Encode()
+	assert.Equal(t, Option{
+		Limit:   10,
+		Offset:  20,
+		SortBy:  "-team,address.city,name",
+		Filters: Filters{GTE{"age", "18"}, Like{"name", "a"}, Search{Value: "bat"}},
+		Fields:  []string{"id", "address.city"},
+	}, OData{}.Parse(query))
+}
+
+func TestODataParseInvalid(t *testing.T) {
+	query := url.Values{"$filter": {"age gt"}, "$top": {"ten"}, "$orderby": {"name up"}}.Encode()
+	assert.Equal(t, ErrValidation{
+		{Param: "$top", Value: "ten", Reason: "invalid integer"},
+		{Param: "$orderby", Value: "name up", Reason: "invalid order, must be '<field> [asc|desc]'"},
+		{Param: "$filter", Value: "age gt", Reason: "syntax error at position 7 : expected value, got end of input"},
+	}, OData{}.Parse(query).Validate(NewSchema(validateHero{})))
+}
+
+func TestODataEncode(t *testing.T) {
+	// options parsed back as is
+	roundTrip := map[string]Option{
+		"page":   {Limit: 10, Offset: 20, SortBy: "-age,address.city", Fields: []string{"id", "address.city"}},
+		"search": {Filters: Filters{EQ{"name", "it's"}, Search{Value: "bat man"}}},
+		"filters": {Filters: Filters{
+			Or{Not{GT{"age", "18"}}, And{EQ{"team", "jla"}, LTE{"address.city", "G"}}},
+			In{"team", []string{"jla", "jsa"}},
+			IsNull{"deletedAt", "true"},
+			IsNull{"name", "false"},
+			EQ{"nick", "null"},
+			Or{Like{"name", "bat"}, StartsWith{"name", "Sup"}, Regex{"name", `(?i)man\.$`}},
+			NE{"a", "1"}, GTE{"b", "2"}, LT{"c", "-3"},
+		}},
+	}
+	for name, opt := range roundTrip {
+		t.Run(name, func(t *testing.T) {
+			query, err := OData{}.Encode("", opt)
+			require.NoError(t, err)
+			assert.Equal(t, opt, OData{}.Parse(query))
+		})
+	}
+
+	opt := Option{Limit: 5, Order: "desc", SortBy: "age", Filters: Filters{
+		And{}, NotIn{"team", []string{"jla"}}, Between{"age", "18", "30"},
+	}}
+	query, err := OData{}.Encode("api_key=secret&$top=3&$search=x", opt)
+	require.NoError(t, err)
+	values, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"api_key":  {"secret"},
+		"$top":     {"5"},
+		"$orderby": {"age desc"},
+		"$filter":  {"not (team in ('jla')) and (age ge '18' and age le '30')"},
+	}, values)
+
+	for name, opt := range map[string]Option{
+		"list":      {Filters: Filters{Contain{"tags", "rich"}}},
+		"regex":     {Filters: Filters{Regex{"name", "^bat"}}},
+		"suffix":    {Filters: Filters{Regex{"name", "man$"}}},
+		"empty-or":  {Filters: Filters{Not{Or{}}}},
+		"seek":      {Filters: Filters{Seek{Keys: []SortKey{{Field: "name"}}, Values: []string{"Bruce"}}}},
+		"field":     {Filters: Filters{EQ{"first name", "Bruce"}}},
+		"collation": {SortBy: "name", Collation: "fr"},
+		"cursor":    {SortBy: "name", Cursor: []string{"Bruce"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := OData{}.Encode("", opt)
+			assert.Error(t, err)
+		})
+	}
+}
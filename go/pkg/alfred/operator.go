@@ -0,0 +1,290 @@
+package alfred
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// compareField return the comparison of the field fv with the filter value.
+// It returns an error if the type of the field is not supported by the operator op.
+func compareField(param, op string, fv reflect.Value, value string) (int, error) {
+	if !slices.Contains(operatorKinds[op], kindOf(fv.Type())) {
+		return 0, fmt.Errorf("filter : unsuported field type (%v as %v)", param, fv.Type().String())
+	}
+	c, err := compareString(fv, value)
+	if err != nil {
+		return 0, ErrParamType{param, err}
+	}
+	return c, nil
+}
+
+// isNil return true if v is a nil pointer, interface, map, slice, func or chan.
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// NE for !=
+type NE struct {
+	Param string
+	Value string
+}
+
+// Keep return true if the struct v has valid fields value according to the filter.
+func (f NE) Keep(v any) (bool, error) {
+	fv, found, err := lookupField(v, f.Param, "ne")
+	if !found {
+		return err == nil, err
+	}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
+	}
+	c, err := compareField(f.Param, "ne", fv, f.Value)
+	return c != 0, err
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "Param <> Value".
+func (f NE) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param <> $n".
+func (f NE) BuildSQL(b *SQLBuilder) string {
+	return fmt.Sprintf("%s <> %s", b.Column(f.Param), b.FieldValue(f.Param, f.Value))
+}
+
+// In filter to test if a value is one of a set of values.
+type In struct {
+	Param  string
+	Values []string
+}
+
+// Keep return true if the struct v has valid fields value according to the filter.
+func (f In) Keep(v any) (bool, error) {
+	fv, found, err := lookupField(v, f.Param, "in")
+	if !found {
+		return err == nil, err
+	}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
+	}
+	for _, value := range f.Values {
+		c, err := compareField(f.Param, "in", fv, value)
+		if err != nil {
+			return false, err
+		}
+		if c == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "Param IN (Value1, Value2, ...)".
+func (f In) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param IN ($1, $2, ...)".
+func (f In) BuildSQL(b *SQLBuilder) string {
+	if len(f.Values) == 0 {
+		return "FALSE"
+	}
+	return fmt.Sprintf("%s IN (%s)", b.Column(f.Param), valuesSQL(b, f.Param, f.Values))
+}
+
+// NotIn filter to test if a value is not one of a set of values.
+type NotIn struct {
+	Param  string
+	Values []string
+}
+
+// Keep return true if the struct v has valid fields value according to the filter.
+func (f NotIn) Keep(v any) (bool, error) {
+	fv, found, err := lookupField(v, f.Param, "nin")
+	if !found {
+		return err == nil, err
+	}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
+	}
+	for _, value := range f.Values {
+		c, err := compareField(f.Param, "nin", fv, value)
+		if err != nil {
+			return false, err
+		}
+		if c == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "Param NOT IN (Value1, Value2, ...)".
+func (f NotIn) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param NOT IN ($1, $2, ...)".
+func (f NotIn) BuildSQL(b *SQLBuilder) string {
+	if len(f.Values) == 0 {
+		return "TRUE"
+	}
+	return fmt.Sprintf("%s NOT IN (%s)", b.Column(f.Param), valuesSQL(b, f.Param, f.Values))
+}
+
+func valuesSQL(b *SQLBuilder, param string, values []string) string {
+	return strings.Join(valueList(b, param, values), ", ")
+}
+
+// Between filter to test if a value is within bounds, inclusive.
+type Between struct {
+	Param string
+	From  string
+	To    string
+}
+
+// Keep return true if the struct v has valid fields value according to the filter.
+func (f Between) Keep(v any) (bool, error) {
+	fv, found, err := lookupField(v, f.Param, "between")
+	if !found {
+		return err == nil, err
+	}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
+	}
+	from, err := compareField(f.Param, "between", fv, f.From)
+	if err != nil {
+		return false, err
+	}
+	to, err := compareField(f.Param, "between", fv, f.To)
+	if err != nil {
+		return false, err
+	}
+	return from >= 0 && to <= 0, nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "Param BETWEEN From AND To".
+func (f Between) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param BETWEEN $1 AND $2".
+func (f Between) BuildSQL(b *SQLBuilder) string {
+	return fmt.Sprintf("%s BETWEEN %s AND %s", b.Column(f.Param), b.FieldValue(f.Param, f.From), b.FieldValue(f.Param, f.To))
+}
+
+// IsNull filter to test if a value is null (Value is true) or not null (Value is false).
+// A value is null if it is a nil pointer, slice, map or interface, or if a nil pointer is on the path of its field.
+type IsNull struct {
+	Param string
+	Value string
+}
+
+// Keep return true if the struct v has valid fields value according to the filter.
+func (f IsNull) Keep(v any) (bool, error) {
+	fv, found, err := lookupField(v, f.Param, "isnull")
+	if !found {
+		return err == nil, err
+	}
+	null, err := strconv.ParseBool(f.Value)
+	if err != nil {
+		return false, ErrParamType{f.Param, err}
+	}
+	return (!fv.IsValid() || isNil(fv)) == null, nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "Param IS NULL" or "Param IS NOT NULL".
+func (f IsNull) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a SQL condition : "Param IS NULL" or "Param IS NOT NULL".
+func (f IsNull) BuildSQL(b *SQLBuilder) string {
+	if null, _ := strconv.ParseBool(f.Value); !null {
+		return fmt.Sprintf("%s IS NOT NULL", b.Column(f.Param))
+	}
+	return fmt.Sprintf("%s IS NULL", b.Column(f.Param))
+}
+
+// StartsWith filter to test if a string starts with a prefix, case insensitive.
+type StartsWith struct {
+	Param string
+	Value string
+}
+
+// Keep return true if the struct v has valid fields value according to the filter.
+func (f StartsWith) Keep(v any) (bool, error) {
+	fv, found, err := lookupField(v, f.Param, "startswith")
+	if !found {
+		return err == nil, err
+	}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
+	}
+	if kindOf(fv.Type()) != kindString {
+		return false, fmt.Errorf("filter : unsuported field type (%v as %v)", f.Param, fv.Type().String())
+	}
+	return strings.HasPrefix(strings.ToLower(fv.String()), strings.ToLower(f.Value)), nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "Param ILIKE Value%".
+func (f StartsWith) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param ILIKE $n ESCAPE '\'" with Value% as argument in PostgreSQL,
+// the wildcards of Value being escaped.
+func (f StartsWith) BuildSQL(b *SQLBuilder) string {
+	return b.dialect().ILike(b.Column(f.Param), b.Value(escapeLike(f.Value)+"%"))
+}
+
+// Regex filter to test if a string matches a regular expression.
+type Regex struct {
+	Param string
+	Value string
+}
+
+// Keep return true if the struct v has valid fields value according to the filter.
+// The regular expression has the syntax of the regexp package.
+func (f Regex) Keep(v any) (bool, error) {
+	fv, found, err := lookupField(v, f.Param, "regex")
+	if !found {
+		return err == nil, err
+	}
+	if !fv.IsValid() {
+		// null value, or nil pointer on the path
+		return false, nil
+	}
+	if kindOf(fv.Type()) != kindString {
+		return false, fmt.Errorf("filter : unsuported field type (%v as %v)", f.Param, fv.Type().String())
+	}
+	re, err := regexp.Compile(f.Value)
+	if err != nil {
+		return false, ErrParamType{f.Param, err}
+	}
+	return re.MatchString(fv.String()), nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "Param ~ Value".
+func (f Regex) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "Param ~ $n" in PostgreSQL.
+func (f Regex) BuildSQL(b *SQLBuilder) string {
+	return b.dialect().Regex(b.Column(f.Param), b.Value(f.Value))
+}
@@ -0,0 +1,146 @@
+package alfred
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOperatorsFromURLValues(t *testing.T) {
+	tests := map[string]struct {
+		url  string
+		want Filter
+	}{
+		"ne":         {url: "?filter[status][ne]=archived", want: NE{"status", "archived"}},
+		"in":         {url: "?filter[status][in]=a,b", want: In{"status", []string{"a", "b"}}},
+		"nin":        {url: "?filter[status][nin]=a", want: NotIn{"status", []string{"a"}}},
+		"between":    {url: "?filter[age][between]=18,30", want: Between{"age", "18", "30"}},
+		"isnull":     {url: "?filter[deletedAt][isnull]=true", want: IsNull{"deletedAt", "true"}},
+		"startswith": {url: "?filter[name][startswith]=bat", want: StartsWith{"name", "bat"}},
+		"regex":      {url: "?filter[name][regex]=^b.*n$", want: Regex{"name", "^b.*n$"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			url, err := url.Parse(tt.url)
+			assert.NoError(t, err)
+			f := ParseURLValues(url.Query())
+			assert.Equal(t, Filters{tt.want}, f.Filters)
+		})
+	}
+
+	f := ParseURLValues(url.Values{"filter[age][between]": {"18"}})
+	assert.Nil(t, f.Filters)
+	assert.Equal(t, ErrValidation{{Param: "age", Op: "between", Value: "18", Reason: "between requires two comma separated values"}}, f.errs)
+}
+
+func TestOperatorsToSQL(t *testing.T) {
+	tests := map[string]struct {
+		filter Filter
+		want   string
+		args   []any
+	}{
+		"ne":         {filter: NE{"status", "archived"}, want: `"status" <> $1`, args: []any{"archived"}},
+		"in":         {filter: In{"age", []string{"18", "30"}}, want: `"age" IN ($1, $2)`, args: []any{"18", "30"}},
+		"in-empty":   {filter: In{"age", nil}, want: `FALSE`},
+		"nin":        {filter: NotIn{"status", []string{"a", "b"}}, want: `"status" NOT IN ($1, $2)`, args: []any{"a", "b"}},
+		"nin-empty":  {filter: NotIn{"status", nil}, want: `TRUE`},
+		"between":    {filter: Between{"age", "18", "30"}, want: `"age" BETWEEN $1 AND $2`, args: []any{"18", "30"}},
+		"isnull":     {filter: IsNull{"deletedAt", "true"}, want: `"deletedAt" IS NULL`},
+		"notnull":    {filter: IsNull{"deletedAt", "false"}, want: `"deletedAt" IS NOT NULL`},
+		"startswith": {filter: StartsWith{"name", "bat"}, want: `"name" ILIKE $1 ESCAPE '\'`, args: []any{"bat%"}},
+		"regex":      {filter: Regex{"name", "^b"}, want: `"name" ~ $1`, args: []any{"^b"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &SQLBuilder{}
+			assert.Equal(t, tt.want, tt.filter.BuildSQL(b))
+			assert.Equal(t, tt.args, b.Args())
+		})
+	}
+	assert.Equal(t, `"status" IN ('a', 'b')`, In{"status", []string{"a", "b"}}.ToSQL())
+	assert.Equal(t, `"age" BETWEEN '18' AND '30'`, Between{"age", "18", "30"}.ToSQL())
+}
+
+func TestOperatorsKeep(t *testing.T) {
+	type hero struct {
+		Name    string  `filter:"name"`
+		Age     int     `filter:"age"`
+		Score   float64 `filter:"score"`
+		Owner   *hero   `filter:"owner"`
+		Friends []string
+	}
+	data := hero{Name: "Bruce", Age: 39, Score: 4.2}
+	tests := map[string]struct {
+		filter Filter
+		want   bool
+	}{
+		"ne-keep":           {filter: NE{"name", "Clark"}, want: true},
+		"ne-drop":           {filter: NE{"age", "39"}, want: false},
+		"in-keep":           {filter: In{"name", []string{"Clark", "Bruce"}}, want: true},
+		"in-drop":           {filter: In{"age", []string{"18", "30"}}, want: false},
+		"nin-keep":          {filter: NotIn{"score", []string{"1.5"}}, want: true},
+		"nin-drop":          {filter: NotIn{"name", []string{"Clark", "Bruce"}}, want: false},
+		"between-keep":      {filter: Between{"age", "18", "39"}, want: true},
+		"between-drop":      {filter: Between{"score", "4.3", "5"}, want: false},
+		"isnull-keep":       {filter: IsNull{"owner", "true"}, want: true},
+		"isnull-drop":       {filter: IsNull{"name", "true"}, want: false},
+		"isnull-slice":      {filter: IsNull{"Friends", "true"}, want: true},
+		"isnull-path":       {filter: IsNull{"owner.name", "true"}, want: true},
+		"notnull-path":      {filter: IsNull{"owner.name", "false"}, want: false},
+		"startswith-keep":   {filter: StartsWith{"name", "bru"}, want: true},
+		"startswith-drop":   {filter: StartsWith{"name", "ruce"}, want: false},
+		"regex-keep":        {filter: Regex{"name", "^B.*e$"}, want: true},
+		"regex-drop":        {filter: Regex{"name", "^b"}, want: false},
+		"nil-path-ne":       {filter: NE{"owner.name", "Clark"}, want: false},
+		"nil-path-nin":      {filter: NotIn{"owner.name", []string{"Clark"}}, want: false},
+		"unknown-field":     {filter: In{"power", []string{"flight"}}, want: true},
+		"nested-startswith": {filter: Not{StartsWith{"owner.name", "a"}}, want: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := tt.filter.Keep(data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, actual)
+		})
+	}
+
+	errs := map[string]Filter{
+		"in-type":         In{"age", []string{"old"}},
+		"between-kind":    Between{"name", "a", "z"},
+		"isnull-value":    IsNull{"name", "maybe"},
+		"startswith-kind": StartsWith{"age", "3"},
+		"regex-invalid":   Regex{"name", "("},
+	}
+	for name, f := range errs {
+		t.Run(name, func(t *testing.T) {
+			_, err := f.Keep(data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidateOperators(t *testing.T) {
+	schema := NewSchema(validateHero{})
+	assert.NoError(t, Option{Filters: Filters{
+		NE{"alive", "false"},
+		In{"age", []string{"18", "30"}},
+		Between{"birth", "2006-05-01T00:00:00Z", "2016-05-01T00:00:00Z"},
+		IsNull{"friends", "true"},
+		StartsWith{"name", "b"},
+		Regex{"name", "^b"},
+	}}.Validate(schema))
+	assert.Equal(t, ErrValidation{
+		{Param: "age", Op: "in", Value: "18,old", Reason: "invalid int value"},
+		{Param: "name", Op: "between", Value: "a,z", Reason: "operator not supported on string field"},
+		{Param: "alive", Op: "isnull", Value: "maybe", Reason: "invalid bool value"},
+		{Param: "name", Op: "regex", Value: "(", Reason: "invalid regular expression"},
+		{Param: "age", Op: "startswith", Value: "1", Reason: "operator not supported on int field"},
+	}, Option{Filters: Filters{
+		In{"age", []string{"18", "old"}},
+		Between{"name", "a", "z"},
+		IsNull{"alive", "maybe"},
+		Regex{"name", "("},
+		StartsWith{"age", "1"},
+	}}.Validate(schema))
+}
@@ -0,0 +1,191 @@
+package alfred
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Parser parses an option from the raw query of a url. Invalid parameters are dropped,
+// and reported by Option.Validate.
+type Parser interface {
+	Parse(rawQuery string) Option
+	// Encode return the raw query of the option in the syntax of the parser, the inverse of Parse,
+	// with the parameters of rawQuery which are not parameters of the parser. It returns an error
+	// if the option cannot be expressed in this syntax.
+	Encode(rawQuery string, opt Option) (string, error)
+}
+
+// errNotEncodableParam is the error of a parameter of an option which cannot be expressed in the syntax.
+func errNotEncodableParam(param, syntax string) error {
+	return fmt.Errorf("filter : %s cannot be encoded as %s", param, syntax)
+}
+
+// checkEncodable return an error if the option has a collation, a null ordering or a cursor,
+// which the syntax does not express.
+func checkEncodable(opt Option, syntax string) error {
+	switch {
+	case opt.Collation != "":
+		return errNotEncodableParam("collation", syntax)
+	case opt.Nulls != "":
+		return errNotEncodableParam("nulls", syntax)
+	case len(opt.Cursor) > 0:
+		return errNotEncodableParam("cursor", syntax)
+	}
+	return nil
+}
+
+// Brackets is the Parser of the 'filter[<field>][<op>]=<value>' format, see ParseURLValues.
+type Brackets struct{}
+
+// Parse the raw query with ParseURLValues.
+func (Brackets) Parse(rawQuery string) Option {
+	values, _ := url.ParseQuery(rawQuery)
+	return ParseURLValues(values)
+}
+
+// Encode return the raw query of the url values of the option, see Option.URLValues.
+func (Brackets) Encode(rawQuery string, opt Option) (string, error) {
+	values, err := opt.URLValues()
+	if err != nil {
+		return "", err
+	}
+	query, _ := url.ParseQuery(rawQuery)
+	for key := range query {
+		if isOptionParam(key) {
+			query.Del(key)
+		}
+	}
+	for key, vs := range values {
+		query[key] = vs
+	}
+	return query.Encode(), nil
+}
+
+// JSONAPI is the Parser of the JSON:API query parameters : 'sort=-age,name', 'page[limit]=10&page[offset]=20'
+// or 'page[size]=10&page[number]=3', and 'fields[<type>]=id,name' (the fields of all types are returned).
+// Filters are 'filter[<field>]=<value>', a comma separated list of values being an 'in' filter,
+// or use the operators, groups and 'q' parameter of ParseURLValues.
+type JSONAPI struct{}
+
+// Parse the raw query as JSON:API query parameters.
+func (JSONAPI) Parse(rawQuery string) Option {
+	values, _ := url.ParseQuery(rawQuery)
+
+	filters := url.Values{}
+	for key, vs := range values {
+		segments := paramSegment.FindAllStringSubmatch(key, -1)
+		switch {
+		case key == "q":
+			filters[key] = vs
+		case !paramFilter.MatchString(key):
+			if strings.HasPrefix(key, "filter[") {
+				filters[key] = vs
+			}
+		case len(segments) == 1:
+			for _, v := range vs {
+				op := "eq"
+				if strings.Contains(v, ",") {
+					op = "in"
+				}
+				filters.Add(key+"["+op+"]", v)
+			}
+		default:
+			filters[key] = vs
+		}
+	}
+	opt := ParseURLValues(filters)
+
+	opt.SortBy = values.Get("sort")
+
+	page := func(key string) int {
+		s := values.Get(key)
+		n, err := strconv.Atoi(s)
+		if err != nil && s != "" {
+			opt.errs = append(opt.errs, ErrInvalidParam{Param: key, Value: s, Reason: "invalid integer"})
+		}
+		return n
+	}
+	opt.Limit, opt.Offset = page("page[limit]"), page("page[offset]")
+	if values.Has("page[size]") || values.Has("page[number]") {
+		opt.Limit = page("page[size]")
+		if number := page("page[number]"); number > 1 {
+			opt.Offset = (number - 1) * opt.Limit
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, "fields[") && strings.HasSuffix(key, "]") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, field := range strings.Split(values.Get(key), ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				opt.Fields = append(opt.Fields, field)
+			}
+		}
+	}
+	return opt
+}
+
+// Encode return the option as JSON:API query parameters, with the filters and 'q' parameter of
+// Option.URLValues. Fields are kept from the 'fields[<type>]' parameters of rawQuery, the types
+// of the fields being unknown. Collations, null orderings and cursors cannot be encoded.
+func (JSONAPI) Encode(rawQuery string, opt Option) (string, error) {
+	if err := checkEncodable(opt, "JSON:API"); err != nil {
+		return "", err
+	}
+	values, err := opt.URLValues()
+	if err != nil {
+		return "", err
+	}
+	query, _ := url.ParseQuery(rawQuery)
+	fields := url.Values{}
+	for key, vs := range query {
+		switch {
+		case strings.HasPrefix(key, "fields["):
+			fields[key] = vs
+		case key != "sort" && key != "q" && !strings.HasPrefix(key, "page[") && !strings.HasPrefix(key, "filter["):
+			continue
+		}
+		query.Del(key)
+	}
+
+	if len(opt.Fields) > 0 {
+		if !slices.Equal(JSONAPI{}.Parse(fields.Encode()).Fields, opt.Fields) {
+			return "", errNotEncodableParam("fields without their type", "JSON:API")
+		}
+		for key, vs := range fields {
+			query[key] = vs
+		}
+	}
+	var sortBy []string
+	for _, key := range opt.SortKeys() {
+		if key.Desc {
+			sortBy = append(sortBy, "-"+key.Field)
+		} else {
+			sortBy = append(sortBy, key.Field)
+		}
+	}
+	if len(sortBy) > 0 {
+		query.Set("sort", strings.Join(sortBy, ","))
+	}
+	if opt.Limit != 0 {
+		query.Set("page[limit]", strconv.Itoa(opt.Limit))
+	}
+	if opt.Offset != 0 {
+		query.Set("page[offset]", strconv.Itoa(opt.Offset))
+	}
+	for key, vs := range values {
+		if key == "q" || strings.HasPrefix(key, "filter[") {
+			query[key] = vs
+		}
+	}
+	return query.Encode(), nil
+}
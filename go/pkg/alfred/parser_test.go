@@ -0,0 +1,160 @@
+package alfred
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrackets(t *testing.T) {
+	query := "filter[name][eq]=Bruce&sortBy=-age&limit=10"
+	values, _ := url.ParseQuery(query)
+	assert.Equal(t, ParseURLValues(values), Brackets{}.Parse(query))
+}
+
+func TestJSONAPIParse(t *testing.T) {
+	tests := map[string]struct {
+		query url.Values
+		want  Option
+	}{
+		"filters": {
+			query: url.Values{
+				"filter[name]":       {"Bruce"},
+				"filter[team]":       {"jla,jsa"},
+				"filter[age][gt]":    {"18"},
+				"filter[not][a][eq]": {"1"},
+				"q":                  {"bat"},
+			},
+			want: Option{Filters: Filters{GT{"age", "18"}, EQ{"name", "Bruce"}, Not{EQ{"a", "1"}}, In{"team", []string{"jla", "jsa"}}, Search{Value: "bat"}}},
+		},
+		"offset": {
+			query: url.Values{"sort": {"-age,name"}, "page[limit]": {"10"}, "page[offset]": {"20"}},
+			want:  Option{SortBy: "-age,name", Limit: 10, Offset: 20},
+		},
+		"number": {
+			query: url.Values{"page[size]": {"10"}, "page[number]": {"3"}},
+			want:  Option{Limit: 10, Offset: 20},
+		},
+		"fields": {
+			query: url.Values{"fields[teams]": {"name"}, "fields[heroes]": {"id, name"}, "limit": {"3"}},
+			want:  Option{Fields: []string{"id", "name", "name"}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, JSONAPI{}.Parse(tt.query.Encode()))
+		})
+	}
+}
+
+func TestJSONAPIParseInvalid(t *testing.T) {
+	query := url.Values{"page[limit]": {"ten"}, "filter[age]x": {"1"}}.Encode()
+	assert.Equal(t, ErrValidation{
+		{Param: "filter[age]x", Reason: "invalid filter"},
+		{Param: "page[limit]", Value: "ten", Reason: "invalid integer"},
+	}, JSONAPI{}.Parse(query).errs)
+}
+
+func TestMiddlewareWith(t *testing.T) {
+	handler := MiddlewareWith(OData{}, NewSchema(httpHero{}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opt, _ := FromContext(r.Context())
+		page, total, err := Apply(httpHeroes, opt)
+		assert.NoError(t, err)
+		assert.NoError(t, WritePage(w, r, opt, page, total))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?$filter="+url.QueryEscape("age lt 100 and not startswith(name, 'C')")+"&$orderby=age", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":[{"name":"Barry","age":28},{"name":"Bruce","age":42}],"total":2,"limit":0,"offset":0}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?$filter="+url.QueryEscape("age lt"), nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"reason":"syntax error at position 7 : expected value, got end of input"`)
+}
+
+func TestJSONAPIEncode(t *testing.T) {
+	opt := Option{
+		Limit:   10,
+		Offset:  20,
+		Order:   "desc",
+		SortBy:  "age,name",
+		Fields:  []string{"id", "name", "name"},
+		Filters: Filters{GT{"age", "18"}, Not{EQ{"a", "1"}}, Search{Value: "bat"}},
+	}
+	query, err := JSONAPI{}.Encode("api_key=secret&page[size]=3&page[number]=2&fields[teams]=name&fields[heroes]=id,name&filter[x]=1", opt)
+	require.NoError(t, err)
+	values, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"api_key":            {"secret"},
+		"fields[teams]":      {"name"},
+		"fields[heroes]":     {"id,name"},
+		"sort":               {"-age,-name"},
+		"page[limit]":        {"10"},
+		"page[offset]":       {"20"},
+		"filter[age][gt]":    {"18"},
+		"filter[not][a][eq]": {"1"},
+		"q":                  {"bat"},
+	}, values)
+	parsed := JSONAPI{}.Parse(query)
+	assert.Equal(t, opt.Filters, parsed.Filters)
+	assert.Equal(t, opt.SortKeys(), parsed.SortKeys())
+
+	_, err = JSONAPI{}.Encode("fields[heroes]=id", opt)
+	assert.EqualError(t, err, "filter : fields without their type cannot be encoded as JSON:API")
+	_, err = JSONAPI{}.Encode("", Option{Collation: "fr"})
+	assert.EqualError(t, err, "filter : collation cannot be encoded as JSON:API")
+}
+
+func TestMiddlewareWithLinks(t *testing.T) {
+	next := regexp.MustCompile(`<([^>]*)>; rel="next"`)
+	tests := map[string]struct {
+		parser Parser
+		query  string
+	}{
+		"brackets": {parser: Brackets{}, query: "filter[age][lt]=100&sortBy=name&limit=1"},
+		"json-api": {parser: JSONAPI{}, query: "filter[age][lt]=100&sort=name&page[size]=1&page[number]=1&fields[heroes]=name"},
+		"odata":    {parser: OData{}, query: "$filter=" + url.QueryEscape("age lt 100") + "&$orderby=name&$top=1&$select=name"},
+		"rql":      {parser: RQL{}, query: "lt(age,100)&sort(+name)&limit(1)&select(name)"},
+		"limits":   {parser: Limits{Parser: RQL{}, MaxLimit: 1}, query: "lt(age,100)&sort(+name)"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := MiddlewareWith(tt.parser, NewSchema(httpHero{}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				opt, _ := FromContext(r.Context())
+				page, total, err := Apply(httpHeroes, opt)
+				require.NoError(t, err)
+				require.NoError(t, WritePage(w, r, opt, page, total))
+			}))
+
+			// follow the next links through the pages
+			var names []string
+			for target := "/heroes?" + tt.query; target != ""; {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var page struct {
+					Data []struct{ Name string }
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+				require.Len(t, page.Data, 1)
+				names = append(names, page.Data[0].Name)
+
+				target = ""
+				if m := next.FindStringSubmatch(rec.Header().Get("Link")); m != nil {
+					target = m[1]
+				}
+				require.Less(t, len(names), 4)
+			}
+			assert.Equal(t, []string{"Barry", "Bruce", "Clark"}, names)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package alfred
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// jsonName return the name of the field in its 'json' tag, or its name. It returns false
+// if the field is ignored by encoding/json.
+func jsonName(sf reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return sf.Name, true
+	default:
+		return name, true
+	}
+}
+
+// Project return the fields of the option of the struct v, or of the struct pointed by v, as a map.
+// Fields are known by name, 'filter' tag or dot separated path to a field of a nested struct, and
+// are keyed by their 'json' tag, nested fields being nested maps. A field with a nil pointer on its
+// path is nil. Without fields, the map has all the fields of v encoded by encoding/json.
+// It returns an error if a field is unknown, or ignored by encoding/json.
+func (flt Option) Project(v any) (map[string]any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("filter : not a struct (%v)", reflect.TypeOf(v))
+	}
+
+	m := map[string]any{}
+	if len(flt.Fields) == 0 {
+		for _, sf := range reflect.VisibleFields(rv.Type()) {
+			if !sf.IsExported() || sf.Anonymous {
+				continue
+			}
+			name, ok := jsonName(sf)
+			if !ok {
+				continue
+			}
+			if fv, ok := fieldByIndex(rv, sf.Index); ok {
+				m[name] = fv.Interface()
+			}
+		}
+		return m, nil
+	}
+	for _, field := range flt.Fields {
+		if err := project(m, rv, strings.Split(field, ".")); err != nil {
+			return nil, fmt.Errorf("filter : unknown field (%v)", field)
+		}
+	}
+	return m, nil
+}
+
+// project set the field at the path of names of the struct rv in m.
+func project(m map[string]any, rv reflect.Value, names []string) error {
+	sf, ok := findField(rv.Type(), names[0])
+	if !ok {
+		return fmt.Errorf("unknown field (%v)", names[0])
+	}
+	key, ok := jsonName(sf)
+	if !ok {
+		return fmt.Errorf("unknown field (%v)", names[0])
+	}
+	fv, ok := fieldByIndex(rv, sf.Index)
+	for ok && fv.Kind() == reflect.Pointer && len(names) > 1 {
+		ok = !fv.IsNil()
+		if ok {
+			fv = fv.Elem()
+		}
+	}
+	switch {
+	case len(names) > 1 && ok && fv.Kind() != reflect.Struct:
+		return fmt.Errorf("not a struct (%v)", names[0])
+	case !ok:
+		// nil pointer on the path
+		m[key] = nil
+		return nil
+	case len(names) == 1:
+		m[key] = fv.Interface()
+		return nil
+	}
+	sub, _ := m[key].(map[string]any)
+	if sub == nil {
+		sub = map[string]any{}
+		m[key] = sub
+	}
+	return project(sub, fv, names[1:])
+}
+
+// projectItems return the projection of the items of the slice v, see Option.Project.
+func (flt Option) projectItems(v any) ([]map[string]any, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("filter : not a slice (%v)", reflect.TypeOf(v))
+	}
+	items := make([]map[string]any, rv.Len())
+	for i := range items {
+		m, err := flt.Project(rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		items[i] = m
+	}
+	return items, nil
+}
@@ -0,0 +1,133 @@
+package alfred
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type projectAddress struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+type projectBase struct {
+	ID int `json:"id"`
+}
+
+type projectHero struct {
+	projectBase
+	Name     string          `filter:"name" json:"name"`
+	Age      int             `filter:"age" json:"age,omitempty"`
+	Secret   string          `json:"-"`
+	Alias    string          `filter:"alias"`
+	Address  *projectAddress `filter:"address" json:"address"`
+	internal string
+}
+
+func TestProject(t *testing.T) {
+	hero := projectHero{
+		projectBase: projectBase{ID: 1},
+		Name:        "Bruce",
+		Age:         42,
+		Secret:      "batman",
+		Alias:       "Batman",
+		Address:     &projectAddress{City: "Gotham", Country: "USA"},
+		internal:    "cave",
+	}
+	tests := map[string]struct {
+		fields []string
+		v      any
+		want   map[string]any
+		err    string
+	}{
+		"all": {
+			v: hero,
+			want: map[string]any{
+				"id": 1, "name": "Bruce", "age": 42, "Alias": "Batman",
+				"address": &projectAddress{City: "Gotham", Country: "USA"},
+			},
+		},
+		"fields": {
+			fields: []string{"ID", "name", "alias"},
+			v:      &hero,
+			want:   map[string]any{"id": 1, "name": "Bruce", "Alias": "Batman"},
+		},
+		"nested": {
+			fields: []string{"name", "address.City", "address.Country"},
+			v:      hero,
+			want:   map[string]any{"name": "Bruce", "address": map[string]any{"city": "Gotham", "country": "USA"}},
+		},
+		"nil-pointer": {
+			fields: []string{"name", "address.City"},
+			v:      projectHero{Name: "Clark"},
+			want:   map[string]any{"name": "Clark", "address": nil},
+		},
+		"unknown": {
+			fields: []string{"name", "power"},
+			v:      hero,
+			err:    "filter : unknown field (power)",
+		},
+		"hidden": {
+			fields: []string{"Secret"},
+			v:      hero,
+			err:    "filter : unknown field (Secret)",
+		},
+		"not-a-struct-path": {
+			fields: []string{"name.first"},
+			v:      hero,
+			err:    "filter : unknown field (name.first)",
+		},
+		"not-a-struct": {
+			fields: []string{"name"},
+			v:      "Bruce",
+			err:    "filter : not a struct (string)",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Option{Fields: tt.fields}.Project(tt.v)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	values, err := url.ParseQuery("fields=id, name,,address.city")
+	require.NoError(t, err)
+	opt := ParseURLValues(values)
+	assert.Equal(t, []string{"id", "name", "address.city"}, opt.Fields)
+	values, err = opt.URLValues()
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"fields": {"id,name,address.city"}}, values)
+}
+
+func TestValidateFields(t *testing.T) {
+	opt := Option{Fields: []string{"name", "address.City", "power"}}
+	assert.Equal(t, ErrValidation{{Param: "fields", Value: "power", Reason: "unknown field"}}, opt.Validate(NewSchema(projectHero{})))
+}
+
+func TestBuildQueryFields(t *testing.T) {
+	opt := Option{Fields: []string{"id", "name", "address.city"}, Limit: 10}
+	query, _ := BuildPSQLQuery("SELECT * FROM heroes", opt)
+	assert.Equal(t, `SELECT "id", "name", ("address")."city" AS "address.city", count(*) OVER() FROM (SELECT * FROM heroes) AS query  LIMIT $1 OFFSET $2`, query)
+	assert.Equal(t, `SELECT "id", "name", count(*) OVER() FROM (SELECT * FROM heroes) AS query  LIMIT ALL OFFSET 0`,
+		AddToPSQLQuery("SELECT * FROM heroes", Option{Fields: []string{"id", "name"}}))
+}
+
+func TestWritePageFields(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httpHandler(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/heroes?sortBy=age&limit=2&fields=name", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":[{"name":"Barry"},{"name":"Clark"}],"total":4,"limit":2,"offset":0}`, rec.Body.String())
+}
@@ -0,0 +1,174 @@
+package alfred
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Querier runs SQL queries, it is implemented by *sql.DB, *sql.Tx and *sql.Conn.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Query run the query filtered, sorted and paginated according to opt on db, and return the rows
+// scanned into structs of type T, and the total number of rows kept by the filters. The dialect is
+// the one of the driver of db if it is a *sql.DB (see DialectOf), PostgreSQL otherwise, and the schema
+// is the one of T. Columns are mapped to the fields of T, see QueryBuilder.
+func Query[T any](ctx context.Context, db Querier, query string, opt Option) ([]T, int, error) {
+	var d Dialect = Postgres{}
+	if sqlDB, ok := db.(*sql.DB); ok {
+		d = DialectOf(sqlDB)
+	}
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, 0, fmt.Errorf("filter : not a struct (%v)", t)
+	}
+	return QueryBuilder[T](ctx, db, &SQLBuilder{Dialect: d, Schema: &Schema{typ: t}}, query, opt)
+}
+
+// QueryBuilder is Query with the builder b, whose arguments must be empty.
+// Columns are mapped to the fields of T by their 'db' tag or the column option of their 'filter' tag,
+// their 'filter' tag, or their name, case insensitive, nested fields by their dot separated path.
+// Other columns, and fields which cannot be set, are ignored. The total is the count(*) OVER()
+// column, or the result of a count query if the page is empty.
+func QueryBuilder[T any](ctx context.Context, db Querier, b *SQLBuilder, query string, opt Option) ([]T, int, error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, 0, fmt.Errorf("filter : not a struct (%v)", t)
+	}
+
+	rows, err := db.QueryContext(ctx, b.Query(query, opt), b.Args()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(columns) == 0 {
+		return nil, 0, fmt.Errorf("filter : no count column")
+	}
+	fields := columns[:len(columns)-1]
+	indexes := make([][]int, len(fields))
+	for i, col := range fields {
+		indexes[i], _ = columnField(t, col)
+	}
+
+	var items []T
+	total := 0
+	dest := make([]any, len(columns))
+	for rows.Next() {
+		var item T
+		v := reflect.ValueOf(&item).Elem()
+		for i, index := range indexes {
+			if index == nil {
+				dest[i] = new(any)
+				continue
+			}
+			fv, ok := allocFieldByIndex(v, index)
+			if !ok {
+				dest[i] = new(any)
+				continue
+			}
+			dest[i] = fv.Addr().Interface()
+		}
+		dest[len(fields)] = &total
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(items) == 0 && opt.Offset > 0 {
+		// past the last page, the count(*) OVER() column has no rows
+		cb := *b
+		cb.args = nil
+		if err := db.QueryRowContext(ctx, cb.CountQuery(query, opt), cb.Args()...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+	return items, total, nil
+}
+
+// CountQuery return the query counting the rows of query kept by the filters of opt, with the same
+// filtered subquery as Query : SELECT count(*) FROM (<query>) AS query WHERE <filter1> ILIKE $1 AND ...
+func (b *SQLBuilder) CountQuery(query string, opt Option) string {
+	defer b.withSchema(opt)()
+	from := fmt.Sprintf("(%s) AS query", query)
+	if where := b.where(b.resolveTimes(opt.filters(), opt)); where != "" {
+		from += " " + where
+	}
+	return "SELECT count(*) FROM " + from
+}
+
+// DialectOf return the dialect of the driver of db : SQLite for the SQLite drivers, MySQL for the
+// MySQL drivers, and PostgreSQL otherwise.
+func DialectOf(db *sql.DB) Dialect {
+	name := strings.ToLower(reflect.TypeOf(db.Driver()).String())
+	switch {
+	case strings.Contains(name, "sqlite"):
+		return SQLite{}
+	case strings.Contains(name, "mysql"):
+		return MySQL{}
+	default:
+		return Postgres{}
+	}
+}
+
+// columnFields caches the fields resolved by columnField.
+var columnFields sync.Map // fieldKey -> []int
+
+// columnField return the index sequence of the field of the struct type t mapped to the column col :
+// the field with the 'db' tag or the 'filter' column option col, the field known as col (see
+// resolveField), or the field named col, case insensitive. Fields of embedded structs are promoted.
+func columnField(t reflect.Type, col string) ([]int, bool) {
+	key := fieldKey{typ: t, path: col}
+	if index, ok := columnFields.Load(key); ok {
+		return index.([]int), index.([]int) != nil
+	}
+	index := func() []int {
+		for _, sf := range reflect.VisibleFields(t) {
+			if name, _, _ := strings.Cut(sf.Tag.Get("db"), ","); sf.IsExported() && (name == col || parseTag(sf).column == col) {
+				return sf.Index
+			}
+		}
+		if index, _, ok := resolveField(t, col); ok {
+			return index
+		}
+		for _, sf := range reflect.VisibleFields(t) {
+			if sf.IsExported() && !sf.Anonymous && strings.EqualFold(sf.Name, col) {
+				return sf.Index
+			}
+		}
+		return nil
+	}()
+	columnFields.Store(key, index)
+	return index, index != nil
+}
+
+// allocFieldByIndex return the field of v at index, allocating the nil pointers on its path.
+// It returns false if a nil pointer cannot be set, such as a pointer to an unexported embedded struct.
+func allocFieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, v.CanSet()
+}
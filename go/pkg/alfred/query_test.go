@@ -0,0 +1,134 @@
+package alfred
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type queryTeam struct {
+	Name string `db:"team"`
+}
+
+type queryHero struct {
+	ID       int    `db:"id"`
+	HeroName string `filter:"name"`
+	Age      *int
+	queryTeam
+	Boss *queryBoss
+}
+
+type queryBoss struct {
+	Name string
+}
+
+func TestQuery(t *testing.T) {
+	db := openSQLite(t)
+	tests := map[string]Option{
+		"all":     {},
+		"filters": {Filters: Filters{Or{EQ{"team", "avengers"}, GT{"age", "100"}}}, SortBy: "-age"},
+		"page":    {SortBy: "name", Limit: 2, Offset: 1},
+		"cursor":  {SortBy: "team,id", Limit: 2, Cursor: []string{"jla", "2"}},
+		"past":    {SortBy: "name", Limit: 2, Offset: 10, Filters: Filters{EQ{"team", "jla"}}},
+		"none":    {Filters: Filters{EQ{"team", "x-men"}}},
+	}
+	for name, opt := range tests {
+		t.Run(name, func(t *testing.T) {
+			heroes, total, err := Query[sqliteHero](context.Background(), db, "SELECT * FROM heroes", opt)
+			require.NoError(t, err)
+
+			want, wantTotal, err := Apply(sqliteHeroes, opt)
+			require.NoError(t, err)
+			assert.Equal(t, wantTotal, total)
+			if len(want) == 0 {
+				assert.Empty(t, heroes)
+			} else {
+				assert.Equal(t, want, heroes)
+			}
+		})
+	}
+}
+
+func TestQueryColumns(t *testing.T) {
+	db := openSQLite(t)
+	opt := Option{SortBy: "id", Limit: 2, Fields: []string{"id", "name", "team", "age", "tags"}}
+	heroes, total, err := Query[queryHero](context.Background(), db, "SELECT id, name, team, CASE WHEN age < 100 THEN age END AS age, tags FROM heroes", opt)
+	require.NoError(t, err)
+	assert.Equal(t, 6, total)
+	assert.Equal(t, []queryHero{
+		{ID: 1, HeroName: "Bruce", Age: ptr(39), queryTeam: queryTeam{Name: "jla"}},
+		{ID: 2, HeroName: "Clark", Age: ptr(35), queryTeam: queryTeam{Name: "jla"}},
+	}, heroes)
+
+	heroes, _, err = Query[queryHero](context.Background(), db, `SELECT id, CASE WHEN age < 100 THEN age END AS age, name AS "Boss.Name", 1 AS unknown FROM heroes`, Option{Filters: Filters{EQ{"id", "3"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []queryHero{{ID: 3, Boss: &queryBoss{Name: "Diana"}}}, heroes)
+}
+
+func TestQueryTx(t *testing.T) {
+	db := openSQLite(t)
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	// a *sql.Tx has no driver, the dialect is given by the builder
+	b := &SQLBuilder{Dialect: SQLite{}, Schema: NewSchema(sqliteHero{})}
+	heroes, total, err := QueryBuilder[sqliteHero](context.Background(), tx, b, "SELECT * FROM heroes", Option{Filters: Filters{Like{"name", "AR"}}, SortBy: "id"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []sqliteHero{sqliteHeroes[1], sqliteHeroes[5]}, heroes)
+}
+
+func TestQueryErrors(t *testing.T) {
+	db := openSQLite(t)
+	_, _, err := Query[int](context.Background(), db, "SELECT * FROM heroes", Option{})
+	assert.EqualError(t, err, "filter : not a struct (int)")
+
+	_, _, err = Query[sqliteHero](context.Background(), db, "SELECT * FROM villains", Option{})
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = Query[sqliteHero](ctx, db, "SELECT * FROM heroes", Option{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDialectOf(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, SQLite{}, DialectOf(db))
+}
+
+func TestCountQuery(t *testing.T) {
+	b := &SQLBuilder{Dialect: Postgres{}}
+	opt := Option{Limit: 10, SortBy: "name", Filters: Filters{GT{"age", "18"}}}
+	assert.Equal(t, `SELECT count(*) FROM (SELECT * FROM heroes) AS query WHERE "age" > $1`, b.CountQuery("SELECT * FROM heroes", opt))
+	assert.Equal(t, []any{"18"}, b.Args())
+	assert.Equal(t, `SELECT count(*) FROM (SELECT * FROM heroes) AS query`, (&SQLBuilder{}).CountQuery("SELECT * FROM heroes", Option{}))
+}
+
+type queryTagHero struct {
+	ID    int    `filter:"id,sortable"`
+	Alias string `filter:"alias,ops=eq|like,sortable,column=name"`
+	Team  string `filter:"team,ops=eq"`
+}
+
+func TestQueryColumnTag(t *testing.T) {
+	db := openSQLite(t)
+	opt := Option{SortBy: "team,-alias", Filters: Filters{Like{"alias", "r"}, EQ{"team", "jla"}}}
+	heroes, total, err := Query[queryTagHero](context.Background(), db, "SELECT * FROM heroes", opt)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []queryTagHero{{ID: 2, Alias: "Clark", Team: "jla"}, {ID: 1, Alias: "Bruce", Team: "jla"}, {ID: 6, Alias: "Barry", Team: "jla"}}, heroes)
+
+	// a filter not allowed matches nothing, rather than being left out
+	opt.Filters = append(opt.Filters, GT{"team", "a"})
+	heroes, total, err = Query[queryTagHero](context.Background(), db, "SELECT * FROM heroes", opt)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, heroes)
+}
@@ -0,0 +1,431 @@
+package alfred
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RQL is the Parser of Resource Query Language queries, '&' separated calls combined with AND
+// ('?and(ge(age,18),lt(age,30))&sort(+name,-age)&limit(10,20)&select(id,name)').
+//
+// The operators are eq, ne, gt, ge, lt, le ('eq(deletedAt,null)' testing null values), in and out
+// ('in(team,jla,jsa)'), contains and like, whose pattern is a glob ('like(name,*bat*)'). Calls are
+// combined with and, or and not. sort sorts by '+' or '-' prefixed fields, limit takes the limit and
+// an optional offset, and select the fields to return, at top level only. 'field=value' is short for
+// eq(field,value). Values are url encoded, ',', '(' and ')' must be escaped.
+type RQL struct{}
+
+// Parse the raw query as a RQL query. The errors of a call are reported with the 'rql' parameter.
+func (RQL) Parse(rawQuery string) Option {
+	opt := Option{}
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		if err := opt.parseRQL(part); err != nil {
+			opt.errs = append(opt.errs, ErrInvalidParam{Param: "rql", Value: part, Reason: err.Error()})
+		}
+	}
+	return opt
+}
+
+// Encode return the option as a RQL query. The parameters of rawQuery are not kept, all the
+// parameters of a RQL query being calls. Between, containsAll and overlaps filters are encoded as
+// groups of comparisons, and pattern filters as globs. Search filters, values that are empty or
+// compared to 'null', collations, null orderings and cursors cannot be encoded.
+func (RQL) Encode(_ string, opt Option) (string, error) {
+	if err := checkEncodable(opt, "RQL"); err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, f := range opt.Filters {
+		call, err := rqlCall(f)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, call)
+	}
+	var sortBy []string
+	for _, key := range opt.SortKeys() {
+		field, err := rqlValue(key.Field)
+		if err != nil {
+			return "", err
+		}
+		if key.Desc {
+			sortBy = append(sortBy, "-"+field)
+		} else {
+			sortBy = append(sortBy, "+"+field)
+		}
+	}
+	if len(sortBy) > 0 {
+		parts = append(parts, "sort("+strings.Join(sortBy, ",")+")")
+	}
+	switch {
+	case opt.Offset != 0:
+		parts = append(parts, fmt.Sprintf("limit(%d,%d)", opt.Limit, opt.Offset))
+	case opt.Limit != 0:
+		parts = append(parts, fmt.Sprintf("limit(%d)", opt.Limit))
+	}
+	if len(opt.Fields) > 0 {
+		fields := make([]string, len(opt.Fields))
+		for i, field := range opt.Fields {
+			var err error
+			if fields[i], err = rqlValue(field); err != nil {
+				return "", err
+			}
+		}
+		parts = append(parts, "select("+strings.Join(fields, ",")+")")
+	}
+	return strings.Join(parts, "&"), nil
+}
+
+// rqlValue return the url encoded value s, a word of a RQL query.
+func rqlValue(s string) (string, error) {
+	if s == "" {
+		return "", errNotEncodableParam("empty value", "RQL")
+	}
+	return strings.ReplaceAll(url.PathEscape(s), "&", "%26"), nil
+}
+
+// rqlCall return the RQL call of the filter f, the inverse of rqlNode.filter.
+func rqlCall(f Filter) (string, error) {
+	call := func(name string, args ...string) (string, error) {
+		words := make([]string, len(args))
+		for i, arg := range args {
+			var err error
+			if words[i], err = rqlValue(arg); err != nil {
+				return "", err
+			}
+		}
+		return name + "(" + strings.Join(words, ",") + ")", nil
+	}
+	group := func(name string, fs []Filter) (string, error) {
+		calls := make([]string, len(fs))
+		for i, sub := range fs {
+			var err error
+			if calls[i], err = rqlCall(sub); err != nil {
+				return "", err
+			}
+		}
+		return name + "(" + strings.Join(calls, ",") + ")", nil
+	}
+	switch f := f.(type) {
+	case And:
+		return group("and", f)
+	case Or:
+		return group("or", f)
+	case Not:
+		return group("not", []Filter{f.Filter})
+	}
+
+	param, op, value, ok := filterOp(f)
+	if !ok {
+		return "", errNotEncodable(f, "unsupported filter type")
+	}
+	each := func(name string, newFilter func(v string) Filter) (string, error) {
+		values := filterValues(f)
+		fs := make([]Filter, len(values))
+		for i, v := range values {
+			fs[i] = newFilter(v)
+		}
+		return group(name, fs)
+	}
+	glob := func(pattern string) (string, error) {
+		// the filter of the glob must be f
+		if !reflect.DeepEqual(globFilter(param, pattern), f) {
+			return "", errNotEncodable(f, "pattern not supported by RQL")
+		}
+		like, err := call("like", param, pattern)
+		// '*' is not escaped
+		return strings.ReplaceAll(like, "%2A", "*"), err
+	}
+
+	switch op {
+	case "eq", "ne", "gt", "lt":
+		if value == "null" {
+			return "", errNotEncodable(f, "null value")
+		}
+		return call(op, param, value)
+	case "gte", "lte":
+		return call(op[:1]+"e", param, value)
+	case "in":
+		return call("in", append([]string{param}, filterValues(f)...)...)
+	case "nin":
+		return call("out", append([]string{param}, filterValues(f)...)...)
+	case "between":
+		f := f.(Between)
+		return group("and", []Filter{GTE{param, f.From}, LTE{param, f.To}})
+	case "contain":
+		return call("contains", param, value)
+	case "containsAll":
+		return each("and", func(v string) Filter { return Contain{param, v} })
+	case "overlaps":
+		return each("or", func(v string) Filter { return Contain{param, v} })
+	case "isnull":
+		null, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", ErrParamType{param, err}
+		}
+		field, err := rqlValue(param)
+		if err != nil {
+			return "", err
+		}
+		if null {
+			return "eq(" + field + ",null)", nil
+		}
+		return "ne(" + field + ",null)", nil
+	case "like":
+		return glob("*" + value + "*")
+	case "startswith":
+		return glob(value + "*")
+	case "regex":
+		// the regular expressions of globs : (?i)^<text>.*<text>$
+		pattern, ok := strings.CutPrefix(value, "(?i)^")
+		if !ok || !strings.HasSuffix(pattern, "$") {
+			break
+		}
+		parts := strings.Split(strings.TrimSuffix(pattern, "$"), ".*")
+		for i, part := range parts {
+			text, ok := regexLiteral(part)
+			if !ok || strings.Contains(text, "*") {
+				return "", errNotEncodable(f, "pattern not supported by RQL")
+			}
+			parts[i] = text
+		}
+		return glob(strings.Join(parts, "*"))
+	}
+	return "", errNotEncodable(f, "operator not supported by RQL")
+}
+
+// parseRQL parse a top level call of a RQL query into the option.
+func (opt *Option) parseRQL(part string) error {
+	if field, value, ok := strings.Cut(part, "="); ok && !strings.Contains(field, "(") {
+		field, err := url.PathUnescape(field)
+		if err != nil {
+			return &SyntaxError{Pos: 1, Msg: "invalid escape in field"}
+		}
+		value, err = url.PathUnescape(value)
+		if err != nil {
+			return &SyntaxError{Pos: len(field) + 2, Msg: "invalid escape in value"}
+		}
+		opt.Filters = append(opt.Filters, EQ{field, value})
+		return nil
+	}
+
+	tokens, err := lex(part, false)
+	if err != nil {
+		return err
+	}
+	r := &tokenReader{tokens: tokens}
+	node, err := parseRQLNode(r)
+	if err != nil {
+		return err
+	}
+	if t := r.next(); t.kind != tokenEOF {
+		return errorAt(t, "unexpected %v", t)
+	}
+
+	switch node.name.text {
+	case "sort":
+		var sortBy []string
+		for _, arg := range node.args {
+			if err := arg.isValue(); err != nil {
+				return err
+			}
+			field := strings.TrimPrefix(arg.name.text, "+")
+			if field == "" || field == "-" {
+				return errorAt(arg.name, "expected field, got %v", arg.name)
+			}
+			sortBy = append(sortBy, field)
+		}
+		opt.SortBy = strings.Join(sortBy, ",")
+	case "limit":
+		if len(node.args) == 0 || len(node.args) > 2 {
+			return errorAt(node.name, "limit takes a limit and an optional offset")
+		}
+		n := make([]int, len(node.args))
+		for i, arg := range node.args {
+			if err := arg.isValue(); err != nil {
+				return err
+			}
+			if n[i], err = strconv.Atoi(arg.name.text); err != nil {
+				return errorAt(arg.name, "invalid integer %v", arg.name)
+			}
+		}
+		opt.Limit = n[0]
+		if len(n) == 2 {
+			opt.Offset = n[1]
+		}
+	case "select":
+		for _, arg := range node.args {
+			if err := arg.isValue(); err != nil {
+				return err
+			}
+			opt.Fields = append(opt.Fields, arg.name.text)
+		}
+	default:
+		f, err := node.filter()
+		if err != nil {
+			return err
+		}
+		opt.Filters = append(opt.Filters, f)
+	}
+	return nil
+}
+
+// rqlNode is a call of a RQL query, or a value if it is not a call.
+type rqlNode struct {
+	name token
+	call bool
+	args []rqlNode
+}
+
+// parseRQLNode parse a node : word ['(' [node (',' node)*] ')']. Words are url decoded.
+func parseRQLNode(r *tokenReader) (rqlNode, error) {
+	name, err := r.expect(tokenWord, "value")
+	if err != nil {
+		return rqlNode{}, err
+	}
+	if name.text, err = url.PathUnescape(name.text); err != nil {
+		return rqlNode{}, errorAt(name, "invalid escape")
+	}
+	node := rqlNode{name: name}
+	if r.peek().kind != tokenLParen {
+		return node, nil
+	}
+	r.next()
+	node.call = true
+	if r.peek().kind == tokenRParen {
+		r.next()
+		return node, nil
+	}
+	for {
+		arg, err := parseRQLNode(r)
+		if err != nil {
+			return rqlNode{}, err
+		}
+		node.args = append(node.args, arg)
+		if t := r.next(); t.kind == tokenRParen {
+			return node, nil
+		} else if t.kind != tokenComma {
+			return rqlNode{}, errorAt(t, "expected ',' or ')', got %v", t)
+		}
+	}
+}
+
+// isValue return an error if the node is a call.
+func (n rqlNode) isValue() error {
+	if n.call {
+		return errorAt(n.name, "expected value, got call %v", n.name)
+	}
+	return nil
+}
+
+// values return the values of the arguments of the node, from the argument at index from.
+func (n rqlNode) values(from int) ([]string, error) {
+	values := make([]string, 0, len(n.args))
+	for _, arg := range n.args[from:] {
+		if err := arg.isValue(); err != nil {
+			return nil, err
+		}
+		values = append(values, arg.name.text)
+	}
+	return values, nil
+}
+
+// filter return the filter of a call.
+func (n rqlNode) filter() (Filter, error) {
+	if !n.call {
+		return nil, errorAt(n.name, "expected call, got %v", n.name)
+	}
+	op := n.name.text
+	switch op {
+	case "and", "or", "not":
+		fs := make([]Filter, len(n.args))
+		for i, arg := range n.args {
+			f, err := arg.filter()
+			if err != nil {
+				return nil, err
+			}
+			fs[i] = f
+		}
+		switch {
+		case op == "and":
+			return And(fs), nil
+		case op == "or":
+			return Or(fs), nil
+		case len(fs) != 1:
+			return nil, errorAt(n.name, "not takes one call")
+		default:
+			return Not{fs[0]}, nil
+		}
+	case "eq", "ne", "gt", "ge", "lt", "le", "contains", "like", "in", "out":
+	case "sort", "limit", "select":
+		return nil, errorAt(n.name, "%v is only allowed at top level", n.name)
+	default:
+		return nil, errorAt(n.name, "unknown operator %v", n.name)
+	}
+
+	if len(n.args) == 0 {
+		return nil, errorAt(n.name, "%v takes a field", n.name)
+	}
+	values, err := n.values(0)
+	if err != nil {
+		return nil, err
+	}
+	field, values := values[0], values[1:]
+	switch op {
+	case "in":
+		return In{field, values}, nil
+	case "out":
+		return NotIn{field, values}, nil
+	}
+	if len(values) != 1 {
+		return nil, errorAt(n.name, "%v takes a field and a value", n.name)
+	}
+	value := values[0]
+	switch op {
+	case "eq", "ne":
+		if value == "null" {
+			return IsNull{field, strconv.FormatBool(op == "eq")}, nil
+		}
+		if op == "eq" {
+			return EQ{field, value}, nil
+		}
+		return NE{field, value}, nil
+	case "gt":
+		return GT{field, value}, nil
+	case "ge":
+		return GTE{field, value}, nil
+	case "lt":
+		return LT{field, value}, nil
+	case "le":
+		return LTE{field, value}, nil
+	case "contains":
+		return Contain{field, value}, nil
+	default:
+		return globFilter(field, value), nil
+	}
+}
+
+// globFilter return the filter of a glob pattern, '*' matching any text : a Like filter for
+// '*text*', a StartsWith filter for 'text*', otherwise a Regex filter, case insensitive as the others.
+func globFilter(field, pattern string) Filter {
+	text := strings.Trim(pattern, "*")
+	switch {
+	case strings.Contains(text, "*"):
+	case pattern == "*"+text+"*":
+		return Like{field, text}
+	case pattern == text+"*":
+		return StartsWith{field, text}
+	}
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return Regex{field, "(?i)^" + strings.Join(parts, ".*") + "$"}
+}
@@ -0,0 +1,138 @@
+package alfred
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRQLParse(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		want  Option
+	}{
+		"comparison": {
+			query: "eq(name,Bruce%20Wayne)&gt(age,18)&ne(team,jla)&ge(a,1)&lt(b,2)&le(c,3)",
+			want: Option{Filters: Filters{
+				EQ{"name", "Bruce Wayne"}, GT{"age", "18"}, NE{"team", "jla"}, GTE{"a", "1"}, LT{"b", "2"}, LTE{"c", "3"},
+			}},
+		},
+		"logic": {
+			query: "or(and(ge(age,18),lt(age,30)),not(in(team,jla,jsa)))&out(name,Clark)",
+			want: Option{Filters: Filters{
+				Or{And{GTE{"age", "18"}, LT{"age", "30"}}, Not{In{"team", []string{"jla", "jsa"}}}},
+				NotIn{"name", []string{"Clark"}},
+			}},
+		},
+		"null": {
+			query: "eq(deletedAt,null)&ne(name,null)",
+			want:  Option{Filters: Filters{IsNull{"deletedAt", "true"}, IsNull{"name", "false"}}},
+		},
+		"like": {
+			query: "like(name,*bat*)&like(name,Sup*)&like(name,*m.n)&contains(tags,rich)",
+			want: Option{Filters: Filters{
+				Like{"name", "bat"}, StartsWith{"name", "Sup"}, Regex{"name", `(?i)^.*m\.n$`}, Contain{"tags", "rich"},
+			}},
+		},
+		"shorthand": {
+			query: "name=Bruce%2C%20Wayne&address.city=Gotham",
+			want:  Option{Filters: Filters{EQ{"name", "Bruce, Wayne"}, EQ{"address.city", "Gotham"}}},
+		},
+		"page": {
+			query: "sort(+name,-age,team)&limit(10,20)&select(id,name)",
+			want:  Option{SortBy: "name,-age,team", Limit: 10, Offset: 20, Fields: []string{"id", "name"}},
+		},
+		"limit": {
+			query: "limit(5)&",
+			want:  Option{Limit: 5},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RQL{}.Parse(tt.query))
+		})
+	}
+
+	// globs are case insensitive, as like and startswith
+	keep, err := RQL{}.Parse("like(name,B*MAN)").Filters.Keep(httpHero{Name: "Batman"})
+	require.NoError(t, err)
+	assert.True(t, keep)
+}
+
+func TestRQLParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"eq(name,Bruce":         "syntax error at position 14 : expected ',' or ')', got end of input",
+		"eq(name,Bruce))":       "syntax error at position 15 : unexpected ')'",
+		"foo(name,Bruce)":       "syntax error at position 1 : unknown operator 'foo'",
+		"eq(name)":              "syntax error at position 1 : 'eq' takes a field and a value",
+		"in()":                  "syntax error at position 1 : 'in' takes a field",
+		"eq(name,lower(Bruce))": "syntax error at position 9 : expected value, got call 'lower'",
+		"and(eq(a,1),b)":        "syntax error at position 13 : expected call, got 'b'",
+		"not(eq(a,1),eq(b,2))":  "syntax error at position 1 : not takes one call",
+		"and(limit(1))":         "syntax error at position 5 : 'limit' is only allowed at top level",
+		"limit(ten)":            "syntax error at position 7 : invalid integer 'ten'",
+		"limit()":               "syntax error at position 1 : limit takes a limit and an optional offset",
+		"sort(-)":               "syntax error at position 6 : expected field, got '-'",
+		"eq(name,%zz)":          "syntax error at position 9 : invalid escape",
+		"(name)":                "syntax error at position 1 : expected value, got '('",
+		"name":                  "syntax error at position 1 : expected call, got 'name'",
+	}
+	for query, reason := range tests {
+		t.Run(query, func(t *testing.T) {
+			assert.Equal(t, ErrValidation{{Param: "rql", Value: query, Reason: reason}}, RQL{}.Parse(query).errs)
+		})
+	}
+}
+
+func TestRQLEncode(t *testing.T) {
+	// options parsed back as is
+	roundTrip := map[string]Option{
+		"page":  {Limit: 10, Offset: 20, SortBy: "-age,address.city", Fields: []string{"id", "name"}},
+		"limit": {Limit: 10},
+		"filters": {Filters: Filters{
+			EQ{"name", "Bruce Wayne"}, GT{"age", "18"}, NE{"team", "a,b&c=(d)"}, GTE{"a", "1"}, LT{"b", "2"}, LTE{"c", "3"},
+			Or{And{GTE{"age", "18"}, LT{"age", "30"}}, Not{In{"team", []string{"jla", "jsa"}}}},
+			NotIn{"name", []string{"Clark"}},
+			IsNull{"deletedAt", "true"}, IsNull{"name", "false"},
+			Contain{"tags", "rich"},
+			Like{"name", "bat man"}, StartsWith{"name", "Sup"}, Regex{"name", `(?i)^a\.b.*c$`},
+		}},
+	}
+	for name, opt := range roundTrip {
+		t.Run(name, func(t *testing.T) {
+			query, err := RQL{}.Encode("", opt)
+			require.NoError(t, err)
+			assert.Equal(t, opt, RQL{}.Parse(query))
+		})
+	}
+
+	query, err := RQL{}.Encode("sort(+name)&limit(3)", Option{
+		Limit:  5,
+		SortBy: "name,-age",
+		Filters: Filters{
+			Between{"age", "18", "30"},
+			ContainsAll{"tags", []string{"rich", "smart"}},
+			Overlaps{"tags", []string{"fast"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "and(ge(age,18),le(age,30))&and(contains(tags,rich),contains(tags,smart))&or(contains(tags,fast))&sort(+name,-age)&limit(5)", query)
+
+	for name, opt := range map[string]Option{
+		"null":      {Filters: Filters{EQ{"name", "null"}}},
+		"empty":     {Filters: Filters{EQ{"name", ""}}},
+		"glob":      {Filters: Filters{Like{"name", "a*b"}}},
+		"regex":     {Filters: Filters{Regex{"name", "^ba+t"}}},
+		"sensitive": {Filters: Filters{Regex{"name", "^a.*b$"}}},
+		"search":    {Filters: Filters{Search{Value: "bat"}}},
+		"nulls":     {SortBy: "name", Nulls: "first"},
+		"cursor":    {SortBy: "name", Cursor: []string{"Bruce"}},
+		"collation": {SortBy: "name", Collation: "fr"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := RQL{}.Encode("", opt)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package alfred
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// valueKind is the kind of value of a field, as understood by filters and sorting.
+type valueKind int
+
+const (
+	kindUnsupported valueKind = iota
+	kindString
+	kindInt
+	kindUint
+	kindFloat
+	kindBool
+	kindTime
+	kindDuration
+	kindList
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindString:
+		return "string"
+	case kindInt:
+		return "int"
+	case kindUint:
+		return "uint"
+	case kindFloat:
+		return "float"
+	case kindBool:
+		return "bool"
+	case kindTime:
+		return "time"
+	case kindDuration:
+		return "duration"
+	case kindList:
+		return "list"
+	default:
+		return "unsupported"
+	}
+}
+
+// kindOf return the kind of values of type t, see scalarOf.
+func kindOf(t reflect.Type) valueKind {
+	return scalarOf(t).kind
+}
+
+// Schema describes the fields of a struct type that can be used to filter and sort.
+type Schema struct {
+	typ reflect.Type
+}
+
+type schemaField struct {
+	kind valueKind
+	// kind of the elements of a list
+	elem valueKind
+	tag  fieldTag
+	// sortable is true if sorting is allowed on the field, see sortable.
+	sortable bool
+}
+
+// NewSchema return the schema of the struct v, or of the struct pointed by v.
+// A field is known by its name, by its 'filter' tag, or by a dot separated path
+// to a field of a nested struct. Fields of embedded structs are promoted.
+//
+// The options of the 'filter' tag declare what is allowed on a field and how it maps to SQL :
+// 'filter:"name,ops=eq|like,sortable,column=hero_name"'. ops is the list of the operators allowed
+// on the field (all of them without ops), sortable allows sorting on the field (all the fields are
+// sortable if no field of the struct is declared sortable), and column is the SQL column of the
+// field (its name in the path without column).
+// It panics if v is not a struct or a pointer to a struct.
+func NewSchema(v any) *Schema {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("call of NewSchema on %v value", t))
+	}
+	return &Schema{typ: t}
+}
+
+// field return the field of the schema known as name.
+func (s *Schema) field(name string) (schemaField, bool) {
+	_, sf, ok := resolveField(s.typ, name)
+	if !ok {
+		return schemaField{}, false
+	}
+	f := schemaField{kind: scalarOf(sf.Type).kind, tag: parseTag(sf), sortable: sortable(s.typ, name)}
+	if f.kind == kindList {
+		f.elem = scalarOf(sf.Type).elem.kind
+	}
+	return f, true
+}
+
+// ParseURLValues is ParseURLValues with the filters and sort keys not allowed by the tags of the
+// fields of the schema left out, see NewSchema. They are reported by Option.Validate, and the
+// SQL queries of the option map its fields to their columns (see SQLBuilder.Schema).
+func (s *Schema) ParseURLValues(values url.Values) Option {
+	return s.restrict(ParseURLValues(values))
+}
+
+// restrict return the option with the filters and sort keys not allowed by the schema left out,
+// and the schema as the schema of its SQL queries.
+func (s *Schema) restrict(opt Option) Option {
+	opt.schema = s
+	opt.errs = slices.Clone(opt.errs)
+
+	var sortBy []string
+	for _, item := range strings.Split(opt.SortBy, ",") {
+		name := strings.TrimLeft(strings.TrimSpace(item), "+-")
+		if f, ok := s.field(name); ok && !f.sortable {
+			opt.errs = append(opt.errs, ErrInvalidParam{Param: "sortBy", Value: name, Reason: "sorting not allowed"})
+			continue
+		}
+		sortBy = append(sortBy, item)
+	}
+	opt.SortBy = strings.Join(sortBy, ",")
+
+	var errs ErrValidation
+	opt.Filters, errs = s.allowed(opt.Filters)
+	opt.errs = append(opt.errs, errs...)
+	return opt
+}
+
+// allowed return the filters without the filters whose operator is not allowed on their field,
+// and the errors of the filters left out. Groups holding a filter not allowed are left out as a
+// whole : pruning them would change what the rest of the group matches.
+func (s *Schema) allowed(fs Filters) (Filters, ErrValidation) {
+	var kept Filters
+	var errs ErrValidation
+	for _, f := range fs {
+		if ferrs := s.notAllowed(f); len(ferrs) > 0 {
+			errs = append(errs, ferrs...)
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, errs
+}
+
+// restricted return the filters with the filters not allowed, and the groups holding them,
+// replaced by an empty Or which matches nothing : leaving them out would widen the results.
+func (s *Schema) restricted(fs Filters) Filters {
+	if len(fs) == 0 {
+		return fs
+	}
+	restricted := make(Filters, len(fs))
+	for i, f := range fs {
+		restricted[i] = f
+		if len(s.notAllowed(f)) > 0 {
+			restricted[i] = Or{}
+		}
+	}
+	return restricted
+}
+
+// notAllowed return the errors of the filters not allowed in the filter f, or in its groups.
+func (s *Schema) notAllowed(f Filter) ErrValidation {
+	var groups []Filter
+	switch f := f.(type) {
+	case And:
+		groups = f
+	case Or:
+		groups = f
+	case Not:
+		groups = []Filter{f.Filter}
+	}
+	if groups != nil {
+		var errs ErrValidation
+		for _, sub := range groups {
+			errs = append(errs, s.notAllowed(sub)...)
+		}
+		return errs
+	}
+	param, op, value, ok := filterOp(f)
+	if !ok {
+		return nil
+	}
+	if field, ok := s.field(param); ok && !field.tag.allows(op) {
+		return ErrValidation{{Param: param, Op: op, Value: value, Reason: "operator not allowed"}}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package alfred
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// searchFieldsCache caches the results of searchFields.
+var searchFieldsCache sync.Map // reflect.Type -> []string
+
+// searchFields return the names of the searchable fields of the struct type t, the string
+// fields with a 'search' option in their 'filter' tag ('filter:"name,search"'), including
+// the fields of embedded structs.
+func searchFields(t reflect.Type) []string {
+	if names, ok := searchFieldsCache.Load(t); ok {
+		return names.([]string)
+	}
+	var names []string
+	visited := map[reflect.Type]bool{}
+	current := []reflect.Type{t}
+	for len(current) > 0 {
+		var next []reflect.Type
+		for _, st := range current {
+			if visited[st] {
+				continue
+			}
+			visited[st] = true
+			for i := 0; i < st.NumField(); i++ {
+				sf := st.Field(i)
+				if sf.Anonymous {
+					et := sf.Type
+					if et.Kind() == reflect.Pointer {
+						et = et.Elem()
+					}
+					if et.Kind() == reflect.Struct {
+						next = append(next, et)
+					}
+				}
+				if !sf.IsExported() || !parseTag(sf).search || kindOf(sf.Type) != kindString {
+					continue
+				}
+				name := tagName(sf)
+				if name == "" {
+					name = sf.Name
+				}
+				names = append(names, name)
+			}
+		}
+		current = next
+	}
+	searchFieldsCache.Store(t, names)
+	return names
+}
+
+// errNoSearchFields is the error of a Search filter without fields on values without searchable fields.
+var errNoSearchFields = errors.New("filter : search without searchable fields")
+
+// Search filter to test if any of the searchable fields contains a text, case insensitive.
+// The searchable fields are Fields, or the string fields with a 'search' option in their
+// 'filter' tag ('filter:"name,search"') if Fields is empty.
+type Search struct {
+	Value  string
+	Fields []string
+}
+
+// fields return the searchable fields of the filter for values of type t.
+func (f Search) fields(t reflect.Type) []string {
+	if len(f.Fields) > 0 {
+		return f.Fields
+	}
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return searchFields(t)
+}
+
+// Keep return true if a searchable field of the struct v contains the text. Null values are
+// not matched. Without searchable fields, as for documents without Fields, it returns an error.
+func (f Search) Keep(v any) (bool, error) {
+	fields := f.fields(reflect.TypeOf(v))
+	if len(fields) == 0 {
+		return false, fmt.Errorf("%w (%T)", errNoSearchFields, v)
+	}
+	for _, field := range fields {
+		fv, found, err := lookupField(v, field, "")
+		if err != nil {
+			return false, err
+		}
+		if !found || !fv.IsValid() {
+			continue
+		}
+		if kindOf(fv.Type()) != kindString {
+			return false, fmt.Errorf("filter : unsuported field type (%v as %v)", field, fv.Type().String())
+		}
+		if stringsContainsI(fv.String(), f.Value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ToSQL return a SQL condition to be used in a SQL query : "(Field1 ILIKE %Value% OR Field2 ILIKE %Value% ...)".
+func (f Search) ToSQL() string {
+	return inlineSQL(f)
+}
+
+// BuildSQL return a parameterized SQL condition : "(Field1 ILIKE $1 OR Field2 ILIKE $2 ...)" with
+// %Value% as arguments in PostgreSQL. With SQLBuilder.FullText, the condition is a PostgreSQL full
+// text search : "to_tsvector(config, coalesce(Field1, '') || ' ' || ...) @@ plainto_tsquery(config, $1)".
+// Without Fields, the searchable fields are SQLBuilder.SearchFields or the ones of SQLBuilder.Schema.
+// Without searchable fields, the condition cannot return the error of Keep and matches nothing : FALSE.
+func (f Search) BuildSQL(b *SQLBuilder) string {
+	fields := f.Fields
+	if len(fields) == 0 {
+		fields = b.SearchFields
+	}
+	if len(fields) == 0 && b.Schema != nil {
+		fields = searchFields(b.Schema.typ)
+	}
+	if len(fields) == 0 {
+		return "FALSE"
+	}
+	if b.FullText != "" {
+		cols := make([]string, len(fields))
+		for i, field := range fields {
+			cols[i] = "coalesce(" + b.Column(field) + ", '')"
+		}
+		config := b.dialect().QuoteLiteral(b.FullText)
+		return fmt.Sprintf("to_tsvector(%s, %s) @@ plainto_tsquery(%s, %s)",
+			config, strings.Join(cols, " || ' ' || "), config, b.Value(f.Value))
+	}
+	or := make(Or, len(fields))
+	for i, field := range fields {
+		or[i] = Like{field, f.Value}
+	}
+	return or.BuildSQL(b)
+}
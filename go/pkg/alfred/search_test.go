@@ -0,0 +1,148 @@
+package alfred
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type searchBase struct {
+	Bio *string `filter:"bio,search"`
+}
+
+type searchHero struct {
+	searchBase
+	Name  string `filter:"name,search"`
+	Alias string `filter:",search"`
+	Team  string `filter:"team"`
+	Age   int    `filter:"age,search"`
+}
+
+var searchHeroes = []searchHero{
+	{Name: "Bruce Wayne", Alias: "Batman", Team: "jla", searchBase: searchBase{Bio: ptr("Billionaire of Gotham")}},
+	{Name: "Clark Kent", Alias: "Superman", Team: "jla"},
+	{Name: "Dick Grayson", Alias: "Nightwing", Team: "batfamily"},
+	{Name: "Diana Prince", Alias: "Wonder Woman", Team: "jla", searchBase: searchBase{Bio: ptr("Princess of Themyscira")}},
+}
+
+func TestSearchFields(t *testing.T) {
+	assert.Equal(t, []string{"name", "Alias", "bio"}, searchFields(reflect.TypeFor[searchHero]()))
+	assert.Empty(t, searchFields(reflect.TypeFor[httpHero]()))
+}
+
+func TestSearchKeep(t *testing.T) {
+	tests := map[string]struct {
+		filter   Search
+		expected []string
+	}{
+		"name":     {Search{Value: "KENT"}, []string{"Clark Kent"}},
+		"alias":    {Search{Value: "man"}, []string{"Bruce Wayne", "Clark Kent", "Diana Prince"}},
+		"embedded": {Search{Value: "gotham"}, []string{"Bruce Wayne"}},
+		"not-team": {Search{Value: "batfamily"}, nil},
+		"fields":   {Search{Value: "bat", Fields: []string{"team", "Alias"}}, []string{"Bruce Wayne", "Dick Grayson"}},
+		"empty":    {Search{Value: ""}, []string{"Bruce Wayne", "Clark Kent", "Dick Grayson", "Diana Prince"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, _, err := Apply(searchHeroes, Option{Filters: Filters{tt.filter}})
+			require.NoError(t, err)
+			var names []string
+			for _, hero := range page {
+				names = append(names, hero.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+
+	_, err := Search{Value: "bat"}.Keep(httpHero{Name: "Bruce"})
+	assert.ErrorIs(t, err, errNoSearchFields)
+	_, _, err = Apply([]httpHero{{Name: "Bruce"}}, Option{Filters: Filters{Search{Value: "bat"}}})
+	assert.ErrorIs(t, err, errNoSearchFields)
+	_, err = Search{Value: "4", Fields: []string{"age"}}.Keep(searchHeroes[0])
+	assert.EqualError(t, err, "filter : unsuported field type (age as int)")
+
+	// documents have no searchable fields
+	_, _, err = Apply([]map[string]any{{"name": "Bruce"}}, Option{Filters: Filters{Search{Value: "bat"}}})
+	assert.EqualError(t, err, "filter : search without searchable fields (map[string]interface {})")
+	_, err = Search{Value: "bat"}.Keep([]byte(`{"name":"Batman"}`))
+	assert.EqualError(t, err, "filter : search without searchable fields ([]uint8)")
+	keep, err := Search{Value: "bat", Fields: []string{"name"}}.Keep([]byte(`{"name":"Batman"}`))
+	assert.NoError(t, err)
+	assert.True(t, keep)
+}
+
+func TestSearchSQL(t *testing.T) {
+	tests := map[string]struct {
+		builder *SQLBuilder
+		filter  Search
+		want    string
+		args    []any
+	}{
+		"fields": {
+			builder: &SQLBuilder{},
+			filter:  Search{Value: "bat", Fields: []string{"name", "alias"}},
+			want:    `("name" ILIKE $1 ESCAPE '\' OR "alias" ILIKE $2 ESCAPE '\')`,
+			args:    []any{"%bat%", "%bat%"},
+		},
+		"schema": {
+			builder: &SQLBuilder{Dialect: SQLite{}, Schema: NewSchema(searchHero{})},
+			filter:  Search{Value: "bat"},
+			want:    `("name" LIKE ? ESCAPE '\' OR "Alias" LIKE ? ESCAPE '\' OR "bio" LIKE ? ESCAPE '\')`,
+			args:    []any{"%bat%", "%bat%", "%bat%"},
+		},
+		"full-text": {
+			builder: &SQLBuilder{Schema: NewSchema(searchHero{}), FullText: "english"},
+			filter:  Search{Value: "dark knight"},
+			want:    `to_tsvector('english', coalesce("name", '') || ' ' || coalesce("Alias", '') || ' ' || coalesce("bio", '')) @@ plainto_tsquery('english', $1)`,
+			args:    []any{"dark knight"},
+		},
+		"builder-fields": {
+			builder: &SQLBuilder{Schema: NewSchema(searchHero{}), SearchFields: []string{"bio"}},
+			filter:  Search{Value: "bat"},
+			want:    `("bio" ILIKE $1 ESCAPE '\')`,
+			args:    []any{"%bat%"},
+		},
+		"no-fields": {
+			builder: &SQLBuilder{},
+			filter:  Search{Value: "bat"},
+			want:    "FALSE",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.BuildSQL(tt.builder))
+			assert.Equal(t, tt.args, tt.builder.Args())
+		})
+	}
+	assert.Equal(t, `("name" ILIKE '%it''s%' ESCAPE '\')`, Search{Value: "it's", Fields: []string{"name"}}.ToSQL())
+}
+
+func TestSearchAddToPSQLQuery(t *testing.T) {
+	opt := ParseURLValues(url.Values{"q": {"batman"}})
+	assert.Equal(t, `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE FALSE LIMIT ALL OFFSET 0`,
+		AddToPSQLQuery("SELECT * FROM heroes", opt))
+	assert.Equal(t, `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE ("name" ILIKE '%batman%' ESCAPE '\' OR "alias" ILIKE '%batman%' ESCAPE '\') LIMIT ALL OFFSET 0`,
+		AddToPSQLQuery("SELECT * FROM heroes", opt, "name", "alias"))
+	query, args := BuildPSQLQuery("SELECT * FROM heroes", opt, "name")
+	assert.Equal(t, `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE ("name" ILIKE $1 ESCAPE '\') LIMIT ALL OFFSET $2`, query)
+	assert.Equal(t, []any{"%batman%", 0}, args)
+}
+
+func TestSearchURL(t *testing.T) {
+	opt := ParseURLValues(url.Values{"q": {"bat"}, "filter[team][eq]": {"jla"}})
+	assert.Equal(t, Filters{EQ{"team", "jla"}, Search{Value: "bat"}}, opt.Filters)
+	values, err := opt.URLValues()
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"q": {"bat"}, "filter[team][eq]": {"jla"}}, values)
+
+	assert.NoError(t, opt.Validate(NewSchema(searchHero{})))
+	assert.Equal(t, ErrValidation{{Param: "q", Value: "bat", Reason: "no searchable fields"}}, Option{Filters: Filters{Search{Value: "bat"}}}.Validate(NewSchema(httpHero{})))
+	err = Option{Filters: Filters{Search{Value: "bat", Fields: []string{"power", "age"}}}}.Validate(NewSchema(searchHero{}))
+	assert.Equal(t, ErrValidation{
+		{Param: "q", Value: "power", Reason: "unknown field"},
+		{Param: "q", Value: "age", Reason: "search not supported on int field"},
+	}, err)
+}
@@ -1,11 +1,16 @@
 package alfred
 
 import (
+	"cmp"
+	"fmt"
 	"reflect"
 	"sort"
-	"strconv"
+	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/collate"
 )
 
 const (
@@ -13,68 +18,231 @@ const (
 	orderDESC = "desc"
 )
 
-// It panics if x is not a slice of structs.
+// SortKey is a field to sort by, and its direction.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// SortKeys return the list of keys to sort by. SortBy is a comma separated list
+// of fields, each one can be prefixed with '-' for descending order or '+' for
+// ascending order ('team,-createdAt'). Fields without prefix use the Order direction.
+func (flt Option) SortKeys() []SortKey {
+	var keys []SortKey
+	desc := strings.EqualFold(strings.TrimSpace(flt.Order), orderDESC)
+	for _, field := range strings.Split(flt.SortBy, ",") {
+		// a '+' in a query string is decoded as a space
+		field = strings.TrimSpace(field)
+		key := SortKey{Field: field, Desc: desc}
+		switch {
+		case strings.HasPrefix(field, "-"):
+			key = SortKey{Field: field[1:], Desc: true}
+		case strings.HasPrefix(field, "+"):
+			key = SortKey{Field: field[1:], Desc: false}
+		}
+		if key.Field == "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Sort sorts the slice according to the sort keys, in lexicographic order of the keys.
+// The sort is stable. Fields are known by name, 'filter' tag or dot separated path to a
+// field of a nested struct, keys not matching a field of the struct are ignored, as well as fields which
+// are not sortable when the struct declares sortable fields (see Schema).
+// Null values, and values with a nil pointer on the path of their field, are lower than any other value,
+// or first or last with Nulls. Strings are ordered by the Collation, if valid (see Option.Validate).
+// Items which are not structs, such as maps and raw JSON documents, are sorted by the values of their
+// fields as looked up by Filters.Keep, values of different types being ordered by type (see compareDynamic).
+// It panics if x is not a slice.
 func (flt Option) Sort(slice any) {
 	v := reflect.ValueOf(slice)
 	if v.Kind() != reflect.Slice {
 		panic("call of Option.Sort on " + v.Kind().String() + " value")
 	}
+	var collator *collate.Collator
+	if flt.Collation != "" {
+		collator, _ = newCollator(flt.Collation)
+	}
+	et := v.Type().Elem()
+	for et.Kind() == reflect.Pointer {
+		et = et.Elem()
+	}
+	if et.Kind() != reflect.Struct {
+		flt.sortDynamic(v, collator)
+		return
+	}
 
-	sort.Slice(slice, func(i, j int) bool {
-		var res bool
-
-		rvi, rvj := v.Index(i), v.Index(j)
-		if rvi.Kind() != reflect.Struct {
-			panic("call of Option.Sort on a slice of" + rvi.Kind().String() + " value")
-		}
-
-		xi, xj := -1, -1
-		for x := 0; x < rvi.NumField(); x++ {
-			ti, tj := rvi.Type().Field(x), rvj.Type().Field(x)
-			// optimize and research for tag also
-			if ti.Name == flt.SortBy {
-				xi = x
+	type sortField struct {
+		index   []int
+		desc    bool
+		scalar  scalarFunc
+		compare func(a, b reflect.Value) int
+	}
+	var fields []sortField
+	for _, key := range flt.SortKeys() {
+		if index, sf, ok := resolveField(et, key.Field); ok && sortable(et, key.Field) {
+			f := sortField{
+				index:   index,
+				desc:    key.Desc,
+				scalar:  scalarOf(sf.Type).scalar,
+				compare: comparator(sf.Type),
 			}
-			if tj.Name == flt.SortBy {
-				xj = x
+			if collator != nil && kindOf(sf.Type) == kindString {
+				f.compare = func(a, b reflect.Value) int { return collator.CompareString(a.String(), b.String()) }
 			}
+			fields = append(fields, f)
 		}
+	}
+	nulls := flt.nulls()
+	if len(fields) == 0 {
+		return
+	}
 
-		if xi < 0 || xj < 0 {
-			return false
+	sort.SliceStable(slice, func(i, j int) bool {
+		rvi, rvj := v.Index(i), v.Index(j)
+		for _, f := range fields {
+			vi, oki := fieldByIndex(rvi, f.index)
+			if oki {
+				vi, oki = f.scalar(vi)
+			}
+			vj, okj := fieldByIndex(rvj, f.index)
+			if okj {
+				vj, okj = f.scalar(vj)
+			}
+			var c int
+			switch {
+			case oki != okj && nulls == nullsFirst:
+				return !oki
+			case oki != okj && nulls == nullsLast:
+				return !okj
+			case !oki || !okj:
+				c = cmp.Compare(btoi(oki), btoi(okj))
+			default:
+				c = f.compare(vi, vj)
+			}
+			if c == 0 {
+				continue
+			}
+			return (c < 0) != f.desc
 		}
+		return false
+	})
+}
 
-		vi, vj := rvi.Field(xi), rvj.Field(xj)
+// dynamicSort sorts items by the values of their sort keys, looked up once per item.
+type dynamicSort struct {
+	swap   func(i, j int)
+	values [][]reflect.Value
+	less   func(a, b []reflect.Value) bool
+}
 
-		if vi.Kind() != vj.Kind() {
-			panic("call of Option.Sort with a slices of different structs")
-		}
+func (s dynamicSort) Len() int           { return len(s.values) }
+func (s dynamicSort) Less(i, j int) bool { return s.less(s.values[i], s.values[j]) }
+func (s dynamicSort) Swap(i, j int) {
+	s.swap(i, j)
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+}
 
-		switch vi.Interface().(type) {
-		case string:
-			res = stringsLess(vi.String(), vj.String())
-		case int, int8, int16, int32, int64:
-			res = vi.Int() < vj.Int()
-		case float32, float64:
-			res = vi.Float() < vj.Float()
-		case bool:
-			res = strconv.FormatBool(vi.Bool()) < strconv.FormatBool(vj.Bool())
-		case time.Time:
-			viTime, ok := vi.Interface().(time.Time)
-			if !ok {
-				panic("NOK")
+// sortDynamic sorts the slice v of items which are not structs, see Option.Sort.
+func (flt Option) sortDynamic(v reflect.Value, collator *collate.Collator) {
+	keys := flt.SortKeys()
+	if len(keys) == 0 || v.Len() < 2 {
+		return
+	}
+	values := make([][]reflect.Value, v.Len())
+	for i := range values {
+		values[i] = make([]reflect.Value, len(keys))
+		for k, key := range keys {
+			// items without the field, or which are not documents, are null
+			if fv, found, err := lookupField(v.Index(i).Interface(), key.Field, ""); found && err == nil {
+				values[i][k] = fv
 			}
-			vjTime, ok := vj.Interface().(time.Time)
-			if !ok {
-				panic("NOK")
+		}
+	}
+	nulls := flt.nulls()
+	sort.Stable(dynamicSort{
+		swap:   reflect.Swapper(v.Interface()),
+		values: values,
+		less: func(a, b []reflect.Value) bool {
+			for k, key := range keys {
+				oka, okb := a[k].IsValid(), b[k].IsValid()
+				var c int
+				switch {
+				case oka != okb && nulls == nullsFirst:
+					return !oka
+				case oka != okb && nulls == nullsLast:
+					return !okb
+				case !oka || !okb:
+					c = cmp.Compare(btoi(oka), btoi(okb))
+				default:
+					c = compareDynamic(a[k], b[k], collator)
+				}
+				if c != 0 {
+					return (c < 0) != key.Desc
+				}
 			}
-			res = viTime.Before(vjTime)
-		default:
 			return false
+		},
+	})
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// comparator return the function comparing the compared values of the fields of type t
+// (see scalarOf). It returns -1, 0 or +1 depending on whether a is less than, equal to,
+// or greater than b. Values of unsupported types are equal.
+func comparator(t reflect.Type) func(a, b reflect.Value) int {
+	switch kindOf(t) {
+	case kindString:
+		return func(a, b reflect.Value) int { return compareStrings(a.String(), b.String()) }
+	case kindInt, kindDuration:
+		return func(a, b reflect.Value) int { return cmp.Compare(a.Int(), b.Int()) }
+	case kindUint:
+		return func(a, b reflect.Value) int { return cmp.Compare(a.Uint(), b.Uint()) }
+	case kindFloat:
+		return func(a, b reflect.Value) int { return cmp.Compare(a.Float(), b.Float()) }
+	case kindBool:
+		return func(a, b reflect.Value) int { return cmp.Compare(btoi(a.Bool()), btoi(b.Bool())) }
+	case kindTime:
+		return func(a, b reflect.Value) int {
+			return a.Interface().(time.Time).Compare(b.Interface().(time.Time))
 		}
+	default:
+		return func(a, b reflect.Value) int { return 0 }
+	}
+}
 
-		return res != (flt.Order == orderDESC)
-	})
+// compareString return -1, 0 or +1 depending on whether v is less than, equal to, or greater than
+// the value s, parsed as a value of the type of v.
+func compareString(v reflect.Value, s string) (int, error) {
+	k := kindOf(v.Type())
+	if k == kindUnsupported {
+		return 0, fmt.Errorf("unsuported field type (%v)", v.Type().String())
+	}
+	c, err := compileCompare(k, s, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return c(v), nil
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case stringsLess(a, b):
+		return -1
+	default:
+		return 1
+	}
 }
 
 // alphabetical order
@@ -85,17 +253,9 @@ func stringsLess(a, b string) bool {
 		return false
 	}
 
-	iRunes := []rune(a)
-	jRunes := []rune(b)
-
-	min := len(iRunes)
-	if min > len(jRunes) {
-		min = len(jRunes)
-	}
-
-	for idx := 0; idx < min; idx++ {
-		ir := iRunes[idx]
-		jr := jRunes[idx]
+	for a != "" && b != "" {
+		ir, isize := utf8.DecodeRuneInString(a)
+		jr, jsize := utf8.DecodeRuneInString(b)
 
 		lir := unicode.ToLower(ir)
 		ljr := unicode.ToLower(jr)
@@ -108,6 +268,8 @@ func stringsLess(a, b string) bool {
 		if ir != jr {
 			return ir < jr
 		}
+
+		a, b = a[isize:], b[jsize:]
 	}
 	return len(a) < len(b)
 }
@@ -48,7 +48,7 @@ func TestSort(t *testing.T) {
 		"float-asc":  {opt: Option{SortBy: "Float", Order: orderASC}, want: []elem{diana, clark, bruce}},
 		"float-desc": {opt: Option{SortBy: "Float", Order: orderDESC}, want: []elem{bruce, clark, diana}},
 		"bool-asc":   {opt: Option{SortBy: "Bool", Order: orderASC}, want: []elem{diana, bruce, clark}},
-		"bool-desc":  {opt: Option{SortBy: "Bool", Order: orderDESC}, want: []elem{clark, bruce, diana}},
+		"bool-desc":  {opt: Option{SortBy: "Bool", Order: orderDESC}, want: []elem{bruce, clark, diana}},
 		"time-asc":   {opt: Option{SortBy: "Time", Order: orderASC}, want: []elem{bruce, clark, diana}},
 		"time-desc":  {opt: Option{SortBy: "Time", Order: orderDESC}, want: []elem{diana, clark, bruce}},
 	}
@@ -60,3 +60,51 @@ func TestSort(t *testing.T) {
 		})
 	}
 }
+
+func TestSortKeys(t *testing.T) {
+	tests := map[string]struct {
+		opt  Option
+		want []SortKey
+	}{
+		"empty":     {opt: Option{}, want: nil},
+		"single":    {opt: Option{SortBy: "name"}, want: []SortKey{{"name", false}}},
+		"order":     {opt: Option{SortBy: "name", Order: "DESC"}, want: []SortKey{{"name", true}}},
+		"multiple":  {opt: Option{SortBy: "team,-createdAt"}, want: []SortKey{{"team", false}, {"createdAt", true}}},
+		"prefix":    {opt: Option{SortBy: " team,+name,,-", Order: orderDESC}, want: []SortKey{{"team", true}, {"name", false}}},
+		"overrides": {opt: Option{SortBy: "-team,name", Order: orderASC}, want: []SortKey{{"team", true}, {"name", false}}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.opt.SortKeys())
+		})
+	}
+}
+
+func TestSortMultiple(t *testing.T) {
+	type hero struct {
+		Team string
+		Name string
+		Age  int
+	}
+	bruce := hero{Team: "jla", Name: "Bruce", Age: 39}
+	diana := hero{Team: "jla", Name: "Diana", Age: 39}
+	clark := hero{Team: "jla", Name: "Clark", Age: 35}
+	tony := hero{Team: "avengers", Name: "Tony", Age: 48}
+	tests := map[string]struct {
+		opt  Option
+		want []hero
+	}{
+		"team-name":      {opt: Option{SortBy: "Team,Name"}, want: []hero{tony, bruce, clark, diana}},
+		"team-desc-name": {opt: Option{SortBy: "-Team,Name"}, want: []hero{bruce, clark, diana, tony}},
+		"age-desc-name":  {opt: Option{SortBy: "-Age,-Name"}, want: []hero{tony, diana, bruce, clark}},
+		"stable":         {opt: Option{SortBy: "Team"}, want: []hero{tony, bruce, diana, clark}},
+		"unknown":        {opt: Option{SortBy: "Unknown"}, want: []hero{bruce, diana, tony, clark}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			arr := []hero{bruce, diana, tony, clark}
+			tt.opt.Sort(arr)
+			assert.Equal(t, tt.want, arr)
+		})
+	}
+}
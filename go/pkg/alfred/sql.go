@@ -0,0 +1,253 @@
+package alfred
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SQLBuilder builds parameterized SQL conditions. Values are replaced by placeholders
+// and collected as query arguments.
+type SQLBuilder struct {
+	// Dialect of the SQL, PostgreSQL if nil.
+	Dialect Dialect
+	// JSONPath renders the path of a nested field as a JSON path ("address"->>'city')
+	// instead of a composite type field ("address")."city".
+	JSONPath bool
+	// Arrays renders the list operators (contain, containsAll, overlaps) on native array
+	// columns instead of comma separated list strings.
+	Arrays bool
+	// Schema of the rows, giving the searchable fields of a Search filter without fields and the
+	// columns of the fields. Filters and sort keys not allowed by the schema are left out (see NewSchema).
+	// The schema of an option parsed by Schema.ParseURLValues is used if nil.
+	Schema *Schema
+	// SearchFields are the searchable fields of a Search filter without fields, the searchable
+	// fields of the schema if empty.
+	SearchFields []string
+	// FullText is the PostgreSQL text search configuration ('english') of a Search filter. If set,
+	// the search is a full text search instead of a case insensitive pattern matching.
+	FullText string
+
+	args []any
+	// inline values as quoted literals instead of placeholders
+	inline bool
+}
+
+// Args return the arguments of the placeholders, in order.
+func (b *SQLBuilder) Args() []any {
+	return b.args
+}
+
+// dialect return the dialect of the builder.
+func (b *SQLBuilder) dialect() Dialect {
+	if b.Dialect == nil {
+		return Postgres{}
+	}
+	return b.Dialect
+}
+
+// Ident return the quoted identifier name.
+func (b *SQLBuilder) Ident(name string) string {
+	return b.dialect().QuoteIdent(name)
+}
+
+// Column return the column of the field path. Nested fields ('address.city') are
+// rendered by Dialect.Path, as fields of a JSON document with JSONPath. With a schema,
+// fields are renamed by the column option of their tag ('filter:"name,column=hero_name"').
+func (b *SQLBuilder) Column(path string) string {
+	names := strings.Split(path, ".")
+	if b.Schema != nil {
+		names = columnPath(b.Schema.typ, path)
+	}
+	if len(names) == 1 {
+		return b.Ident(names[0])
+	}
+	return b.dialect().Path(names, b.JSONPath)
+}
+
+// withSchema set the schema of opt as the schema of the builder if it has none, and return
+// the function restoring it.
+func (b *SQLBuilder) withSchema(opt Option) func() {
+	if b.Schema != nil || opt.schema == nil {
+		return func() {}
+	}
+	b.Schema = opt.schema
+	return func() { b.Schema = nil }
+}
+
+// Value add the filter value s to the arguments as a string and return its placeholder.
+func (b *SQLBuilder) Value(s string) string {
+	if b.inline {
+		return b.dialect().QuoteLiteral(s)
+	}
+	return b.Arg(s)
+}
+
+// FieldValue add the filter value s of the field path to the arguments and return its placeholder.
+// With a schema, the argument is typed as the field, or its elements for a list : an int64, a uint64,
+// a float64, a bool, a time.Time or a time.Duration. Values of unknown fields, values which are not
+// valid for their field, and values without schema are strings, as their type cannot be told from
+// their text ('12345' may be a zip code).
+func (b *SQLBuilder) FieldValue(path, s string) string {
+	if b.inline || b.Schema == nil {
+		return b.Value(s)
+	}
+	f, ok := b.Schema.field(path)
+	if !ok {
+		return b.Value(s)
+	}
+	kind := f.kind
+	if kind == kindList {
+		kind = f.elem
+	}
+	v, err := typedValue(kind, s)
+	if err != nil {
+		return b.Value(s)
+	}
+	return b.Arg(v)
+}
+
+// Arg add v to the arguments and return its placeholder.
+func (b *SQLBuilder) Arg(v any) string {
+	if b.inline {
+		return b.dialect().QuoteLiteral(fmt.Sprint(v))
+	}
+	b.args = append(b.args, v)
+	return b.dialect().Placeholder(len(b.args))
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns and the escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike return s escaped to be matched literally in a LIKE pattern, see Dialect.ILike.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// inlineSQL return the condition of the filter with values inlined as quoted literals.
+func inlineSQL(f Filter) string {
+	return f.BuildSQL(&SQLBuilder{inline: true})
+}
+
+// BuildPSQLQuery add filter to a pq query, like AddToPSQLQuery, but return a
+// parameterized query and its arguments, to be used with db.QueryContext(ctx, query, args...).
+// SELECT *, count(*) OVER() FROM (<query>) AS query WHERE <filter1> ILIKE $1 AND ... ORDER BY <sortBy1> <order1>, ... LIMIT $n OFFSET $m
+// searchFields are the fields searched by a Search filter without fields, see SQLBuilder.SearchFields.
+func BuildPSQLQuery(query string, opt Option, searchFields ...string) (string, []any) {
+	return BuildQuery(Postgres{}, query, opt, searchFields...)
+}
+
+// BuildQuery is BuildPSQLQuery for the SQL dialect d.
+func BuildQuery(d Dialect, query string, opt Option, searchFields ...string) (string, []any) {
+	b := &SQLBuilder{Dialect: d, SearchFields: searchFields}
+	return b.Query(query, opt), b.Args()
+}
+
+// Query return the query filtered, sorted and paginated according to opt, like BuildPSQLQuery,
+// using the options of the builder. The arguments are added to the builder.
+func (b *SQLBuilder) Query(query string, opt Option) string {
+	defer b.withSchema(opt)()
+	query = fmt.Sprintf("SELECT %v, count(*) OVER() FROM (%v) AS query %v", b.columns(opt.Fields), query, b.where(b.resolveTimes(opt.filters(), opt)))
+
+	// sorting
+	if keys := opt.SortKeys(); len(keys) > 0 {
+		var orderBy []string
+		for _, key := range keys {
+			if !b.sortable(key.Field) {
+				continue
+			}
+			col := b.Column(key.Field)
+			if opt.Collation != "" && b.isString(key.Field) {
+				col = b.dialect().Collate(col, opt.Collation)
+			}
+			// null values are explicitly ordered as in Option.Sort, whatever the database default
+			orderBy = append(orderBy, b.dialect().OrderBy(col, key.Desc, keyNulls(opt.nulls(), key.Desc)))
+		}
+		if len(orderBy) > 0 {
+			query = fmt.Sprintf("%v ORDER BY %v", query, strings.Join(orderBy, ", "))
+		}
+	}
+	// pagination
+	limit := b.dialect().NoLimit()
+	if opt.Limit > 0 {
+		limit = b.limit(opt.Limit)
+	}
+	offset := opt.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	return fmt.Sprintf("%v LIMIT %v OFFSET %v", query, limit, b.limit(offset))
+}
+
+// columns return the select list of the fields, '*' without fields. Nested fields are
+// named by their path.
+func (b *SQLBuilder) columns(fields []string) string {
+	if len(fields) == 0 {
+		return "*"
+	}
+	cols := make([]string, len(fields))
+	for i, field := range fields {
+		cols[i] = b.Column(field)
+		if strings.Contains(field, ".") {
+			cols[i] += " AS " + b.Ident(field)
+		}
+	}
+	return strings.Join(cols, ", ")
+}
+
+// isString return true if the field is a string field of the schema, or if the builder has no schema.
+func (b *SQLBuilder) isString(field string) bool {
+	if b.Schema == nil {
+		return true
+	}
+	f, ok := b.Schema.field(field)
+	return ok && f.kind == kindString
+}
+
+// sortable return true if sorting is allowed on the field by the schema, or if the builder has no schema.
+// Unknown fields are sortable.
+func (b *SQLBuilder) sortable(field string) bool {
+	if b.Schema == nil {
+		return true
+	}
+	f, ok := b.Schema.field(field)
+	return !ok || f.sortable
+}
+
+// resolveTimes return the filters with the values of the time fields resolved against opt.Now
+// (see parseTime) : the time fields of the schema of the builder, or of the schema opt was parsed
+// with. Without schema, the time fields are not known and the values are left as is.
+func (b *SQLBuilder) resolveTimes(fs Filters, opt Option) Filters {
+	schema := b.Schema
+	if schema == nil {
+		schema = opt.schema
+	}
+	if schema == nil {
+		return fs
+	}
+	return resolveTimes(schema.typ, fs, opt.now())
+}
+
+// where return the WHERE clause of the filters, empty without filters. With a schema, the filters
+// not allowed, and the groups holding them, match nothing (see Schema.restricted).
+func (b *SQLBuilder) where(filters Filters) string {
+	if b.Schema != nil {
+		filters = b.Schema.restricted(filters)
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	where := make([]string, len(filters))
+	for i, fv := range filters {
+		where[i] = fv.BuildSQL(b)
+	}
+	return fmt.Sprintf("WHERE %s", strings.Join(where, " AND "))
+}
+
+// limit return a placeholder for a pagination value, inlined as a number in inline mode.
+func (b *SQLBuilder) limit(n int) string {
+	if b.inline {
+		return strconv.Itoa(n)
+	}
+	return b.Arg(n)
+}
@@ -0,0 +1,110 @@
+package alfred
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sqlHero struct {
+	Name  string    `filter:"name"`
+	Age   int       `filter:"age"`
+	Code  string    `filter:"code"`
+	Score float64   `filter:"score"`
+	Birth time.Time `filter:"birth"`
+	Tags  string    `filter:"tags"`
+}
+
+func TestBuildSQL(t *testing.T) {
+	tests := map[string]struct {
+		filter Filter
+		want   string
+		args   []any
+	}{
+		"like":           {filter: Like{"name", "batman"}, want: `"name" ILIKE $1 ESCAPE '\'`, args: []any{"%batman%"}},
+		"like-wildcards": {filter: Like{"name", `50%_off\`}, want: `"name" ILIKE $1 ESCAPE '\'`, args: []any{`%50\%\_off\\%`}},
+		"eq":             {filter: EQ{"name", "batman"}, want: `"name" = $1`, args: []any{"batman"}},
+		"eq-int":         {filter: EQ{"age", "42"}, want: `"age" = $1`, args: []any{int64(42)}},
+		"eq-text":        {filter: EQ{"code", "007"}, want: `"code" = $1`, args: []any{"007"}},
+		"gt":             {filter: GT{"age", "42"}, want: `"age" > $1`, args: []any{int64(42)}},
+		"gte":            {filter: GTE{"score", "4.2"}, want: `"score" >= $1`, args: []any{4.2}},
+		"lt": {
+			filter: LT{"birth", "2006-05-01T00:00:00Z"},
+			want:   `"birth" < $1`,
+			args:   []any{time.Date(2006, time.May, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		"lte":     {filter: LTE{"age", "42"}, want: `"age" <= $1`, args: []any{int64(42)}},
+		"contain": {filter: Contain{"tags", "hero"}, want: `string_to_array("tags", ',') @> ARRAY[$1]`, args: []any{"hero"}},
+		"or": {
+			filter: Or{EQ{"name", "bruce"}, Not{GT{"age", "42"}}},
+			want:   `("name" = $1 OR NOT ("age" > $2))`,
+			args:   []any{"bruce", int64(42)},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &SQLBuilder{Schema: NewSchema(sqlHero{})}
+			assert.Equal(t, tt.want, tt.filter.BuildSQL(b))
+			assert.Equal(t, tt.args, b.Args())
+		})
+	}
+}
+
+func TestBuildSQLWithoutSchema(t *testing.T) {
+	// the type of the values cannot be told from their text
+	b := &SQLBuilder{}
+	f := And{EQ{"zip", "01234"}, EQ{"age", "42"}, In{"code", []string{"7", "12"}}, LT{"birth", "2006-05-01T00:00:00Z"}}
+	assert.Equal(t, `("zip" = $1 AND "age" = $2 AND "code" IN ($3, $4) AND "birth" < $5)`, f.BuildSQL(b))
+	assert.Equal(t, []any{"01234", "42", "7", "12", "2006-05-01T00:00:00Z"}, b.Args())
+
+	// values of unknown fields, or not valid for their field, are strings
+	b = &SQLBuilder{Schema: NewSchema(sqlHero{})}
+	f = And{EQ{"zip", "01234"}, EQ{"age", "old"}}
+	assert.Equal(t, `("zip" = $1 AND "age" = $2)`, f.BuildSQL(b))
+	assert.Equal(t, []any{"01234", "old"}, b.Args())
+}
+
+func TestBuildPSQLQuery(t *testing.T) {
+	tests := map[string]struct {
+		opt  Option
+		want string
+		args []any
+	}{
+		"empty": {
+			opt:  Option{},
+			want: `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query  LIMIT ALL OFFSET $1`,
+			args: []any{0},
+		},
+		"full": {
+			opt: Option{
+				Limit:   10,
+				Offset:  20,
+				SortBy:  "team,-name",
+				Order:   "asc",
+				Filters: Filters{Like{"name", "bat"}, GT{"age", "18"}},
+			},
+			want: `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE "name" ILIKE $1 ESCAPE '\' AND "age" > $2 ORDER BY "team" ASC NULLS FIRST, "name" DESC NULLS LAST LIMIT $3 OFFSET $4`,
+			args: []any{"%bat%", "18", 10, 20},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, args := BuildPSQLQuery("SELECT * FROM heroes", tt.opt)
+			assert.Equal(t, tt.want, query)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestBuildQueryPermissions(t *testing.T) {
+	b := &SQLBuilder{Schema: NewSchema(tagHero{})}
+	query := b.Query("SELECT * FROM heroes", Option{
+		SortBy:  "secret,-address.city",
+		Fields:  []string{"name", "address.city"},
+		Filters: Filters{EQ{"name", "Bruce"}, GTE{"age", "18"}, Or{EQ{"team", "jla"}}, Not{Between{"age", "18", "40"}}},
+	})
+	assert.Equal(t, `SELECT "hero_name", ("addr")."town" AS "address.city", count(*) OVER() FROM (SELECT * FROM heroes) AS query `+
+		`WHERE "hero_name" = $1 AND FALSE AND FALSE AND NOT ("age" BETWEEN $2 AND $3) ORDER BY ("addr")."town" DESC NULLS LAST LIMIT ALL OFFSET $4`, query)
+	assert.Equal(t, []any{"Bruce", int64(18), int64(40), 0}, b.Args())
+}
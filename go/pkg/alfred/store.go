@@ -0,0 +1,132 @@
+package alfred
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// storeSchema is the schema of the queries of a document store, see MongoBuilder and ElasticBuilder.
+// Without schema, fields keep their path and values are strings.
+type storeSchema struct {
+	schema *Schema
+}
+
+// newStoreSchema return the schema of the builder, or the schema of opt if the builder has none.
+func newStoreSchema(schema *Schema, opt Option) storeSchema {
+	if schema == nil {
+		schema = opt.schema
+	}
+	return storeSchema{schema: schema}
+}
+
+// filters return the filters of opt with the cursor, relative times resolved against opt.Now, and
+// the filters not allowed by the schema, with the groups holding them, matching nothing.
+func (s storeSchema) filters(opt Option) Filters {
+	fs := opt.filters()
+	if s.schema == nil {
+		return fs
+	}
+	return s.schema.restricted(resolveTimes(s.schema.typ, fs, opt.now()))
+}
+
+// sortKeys return the sort keys of opt, without the keys not allowed by the schema.
+func (s storeSchema) sortKeys(opt Option) []SortKey {
+	var keys []SortKey
+	for _, key := range opt.SortKeys() {
+		if s.schema != nil {
+			if f, ok := s.schema.field(key.Field); ok && !f.sortable {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// field return the name of the field path in the documents, renamed by the column option of
+// the tags of the fields ('address.city').
+func (s storeSchema) field(path string) string {
+	if s.schema == nil {
+		return path
+	}
+	return strings.Join(columnPath(s.schema.typ, path), ".")
+}
+
+// kind return the kind of the field, the kind of its elements for a list, and false if the field
+// is not known by the schema.
+func (s storeSchema) kind(path string) (kind, elem valueKind, ok bool) {
+	if s.schema == nil {
+		return kindUnsupported, kindUnsupported, false
+	}
+	f, ok := s.schema.field(path)
+	return f.kind, f.elem, ok
+}
+
+// isString return true if the field is a string field of the schema : a comma separated list
+// for the list operators, instead of an array.
+func (s storeSchema) isString(path string) bool {
+	kind, _, ok := s.kind(path)
+	return ok && kind == kindString
+}
+
+// values return the filter values of the field typed as the field, or its elements for a list :
+// a string, an int64, a uint64, a float64, a bool, a time.Time or a time.Duration. Values of
+// unknown fields, or without schema, are strings : their type cannot be told from their text
+// ('12345' may be a zip code).
+func (s storeSchema) values(path string, values []string) ([]any, error) {
+	kind, elem, ok := s.kind(path)
+	if kind == kindList {
+		kind = elem
+	}
+	typed := make([]any, len(values))
+	for i, value := range values {
+		if !ok {
+			typed[i] = value
+			continue
+		}
+		v, err := typedValue(kind, value)
+		if err != nil {
+			return nil, ErrParamType{path, err}
+		}
+		typed[i] = v
+	}
+	return typed, nil
+}
+
+// typedValue return the value s of a field of kind k, s for the kinds without Go value.
+func typedValue(k valueKind, s string) (any, error) {
+	switch k {
+	case kindInt:
+		return strconv.ParseInt(s, 10, 64)
+	case kindUint:
+		return strconv.ParseUint(s, 10, 64)
+	case kindFloat:
+		return strconv.ParseFloat(s, 64)
+	case kindBool:
+		return strconv.ParseBool(s)
+	case kindTime:
+		return parseTime(s, time.Now())
+	case kindDuration:
+		return time.ParseDuration(s)
+	default:
+		return s, nil
+	}
+}
+
+// searchFields return the fields searched by the filter, the searchable fields of the schema without fields.
+func (s storeSchema) searchFields(f Search) []string {
+	if len(f.Fields) == 0 && s.schema != nil {
+		return searchFields(s.schema.typ)
+	}
+	return f.Fields
+}
+
+// nullable return the fields whose null values are matched by neither the filter f, nor its
+// negation, the searchable fields of the schema for a Search without fields (see nullableFields).
+func (s storeSchema) nullable(f Filter) []string {
+	if search, ok := f.(Search); ok {
+		return s.searchFields(search)
+	}
+	return nullableFields(f)
+}
@@ -0,0 +1,131 @@
+package alfred
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type storeAddress struct {
+	City string `filter:"city,sortable,column=town"`
+}
+
+type storeHero struct {
+	Name     string        `filter:"name,search,sortable"`
+	Age      int           `filter:"age,sortable"`
+	Score    float64       `filter:"score"`
+	Alive    bool          `filter:"alive"`
+	Birth    time.Time     `filter:"birth,sortable"`
+	Cooldown time.Duration `filter:"cooldown"`
+	Tags     []string      `filter:"tags"`
+	Ranks    []uint        `filter:"ranks"`
+	Powers   string        `filter:"powers"`
+	Team     string        `filter:"team,ops=eq|in,column=team_name"`
+	Address  storeAddress  `filter:"address"`
+}
+
+func TestStoreSchemaValues(t *testing.T) {
+	s := storeSchema{schema: NewSchema(storeHero{})}
+	birth := time.Date(1939, time.May, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		field  string
+		values []string
+		want   []any
+		err    string
+	}{
+		"string":       {field: "name", values: []string{"42"}, want: []any{"42"}},
+		"int":          {field: "age", values: []string{"42", "-1"}, want: []any{int64(42), int64(-1)}},
+		"float":        {field: "score", values: []string{"1.5"}, want: []any{1.5}},
+		"bool":         {field: "alive", values: []string{"true"}, want: []any{true}},
+		"time":         {field: "birth", values: []string{"1939-05-01"}, want: []any{birth}},
+		"duration":     {field: "cooldown", values: []string{"1h30m"}, want: []any{90 * time.Minute}},
+		"list":         {field: "ranks", values: []string{"1"}, want: []any{uint64(1)}},
+		"nested":       {field: "address.city", values: []string{"1"}, want: []any{"1"}},
+		"unknown":      {field: "power", values: []string{"1", "1.5", "x"}, want: []any{"1", "1.5", "x"}},
+		"invalid-int":  {field: "age", values: []string{"old"}, err: `filter: field 'age' : strconv.ParseInt: parsing "old": invalid syntax`},
+		"invalid-elem": {field: "ranks", values: []string{"-1"}, err: `filter: field 'ranks' : strconv.ParseUint: parsing "-1": invalid syntax`},
+		"invalid-time": {field: "birth", values: []string{"last week"}, err: "filter: field 'birth' : " + errTimeFormat.Error()},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			values, err := s.values(tt.field, tt.values)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, values)
+		})
+	}
+
+	// without schema, the type of a value cannot be told from its text
+	values, err := storeSchema{}.values("zip", []string{"12345", "2024-05-01T00:00:00Z"})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"12345", "2024-05-01T00:00:00Z"}, values)
+}
+
+func TestStoreSchemaField(t *testing.T) {
+	s := storeSchema{schema: NewSchema(storeHero{})}
+	assert.Equal(t, "team_name", s.field("team"))
+	assert.Equal(t, "address.town", s.field("address.city"))
+	assert.Equal(t, "address.zip", s.field("address.zip"))
+	assert.Equal(t, "address.city", storeSchema{}.field("address.city"))
+
+	assert.Equal(t, []SortKey{{"name", false}, {"power", true}}, s.sortKeys(Option{SortBy: "name,-team,-power"}))
+	assert.Equal(t, []string{"name"}, s.searchFields(Search{Value: "bat"}))
+}
+
+func TestSeekExpand(t *testing.T) {
+	tests := map[string]struct {
+		seek Seek
+		want Filter
+	}{
+		"none": {seek: Seek{}, want: Or{}},
+		"one":  {seek: Seek{Keys: []SortKey{{"name", false}}, Values: []string{"Bruce"}}, want: Or{GT{"name", "Bruce"}}},
+		"mixed": {
+			seek: Seek{Keys: []SortKey{{"team", false}, {"age", true}, {"id", false}}, Values: []string{"jla", "40", "3"}},
+			want: Or{
+				GT{"team", "jla"},
+				And{EQ{"team", "jla"}, Or{LT{"age", "40"}, IsNull{"age", "true"}}},
+				And{EQ{"team", "jla"}, EQ{"age", "40"}, GT{"id", "3"}},
+			},
+		},
+		"nulls-first": {
+			seek: Seek{Keys: []SortKey{{"age", true}}, Values: []string{"40"}, Nulls: "first"},
+			want: Or{LT{"age", "40"}},
+		},
+		"nulls-last": {
+			seek: Seek{Keys: []SortKey{{"age", false}}, Values: []string{"40"}, Nulls: "last"},
+			want: Or{Or{GT{"age", "40"}, IsNull{"age", "true"}}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.seek.expand())
+		})
+	}
+}
+
+func TestNegate(t *testing.T) {
+	tests := map[string]struct {
+		filter Filter
+		want   Filter
+	}{
+		"leaf":  {filter: EQ{"name", "Bruce"}, want: Not{EQ{"name", "Bruce"}}},
+		"and":   {filter: And{EQ{"a", "1"}, Or{EQ{"b", "1"}, Not{EQ{"c", "1"}}}}, want: Or{Not{EQ{"a", "1"}}, And{Not{EQ{"b", "1"}}, EQ{"c", "1"}}}},
+		"none":  {filter: And{}, want: Or{}},
+		"not":   {filter: Not{EQ{"a", "1"}}, want: EQ{"a", "1"}},
+		"empty": {filter: Or{}, want: And{}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, negate(tt.filter))
+		})
+	}
+
+	s := storeSchema{NewSchema(storeHero{})}
+	assert.Equal(t, []string{"age"}, s.nullable(GT{"age", "1"}))
+	assert.Empty(t, s.nullable(IsNull{"age", "true"}))
+	assert.Equal(t, []string{"name"}, s.nullable(Search{Value: "bat"}))
+}
@@ -0,0 +1,179 @@
+package alfred
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	unixTime     = regexp.MustCompile(`^-?[0-9]+(\.[0-9]{1,9})?$`)
+	relativeTime = regexp.MustCompile(`^(now|today|yesterday|tomorrow)((?:[+-][0-9]+[smhdwMy])*)$`)
+	timeOffset   = regexp.MustCompile(`([+-])([0-9]+)([smhdwMy])`)
+)
+
+// errTimeFormat is the error of a time value in none of the supported formats.
+var errTimeFormat = errors.New("supported formats time.RFC3339, date (2006-01-02), unix timestamp, or now, today, yesterday, tomorrow with offsets (now-7d)")
+
+// parseTime parse a time value, in the format time.RFC3339, a date ('2006-01-02', at midnight UTC),
+// a unix timestamp in seconds ('1714521600', '1714521600.5'), or a time relative to now : now,
+// today, yesterday or tomorrow (at midnight UTC), followed by offsets ('now-7d', 'today+9h30m').
+// The units of the offsets are s, m, h, d (days), w (weeks), M (months) and y (years).
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	if unixTime.MatchString(s) {
+		sec, frac, _ := strings.Cut(s, ".")
+		secs, err := strconv.ParseInt(sec, 10, 64)
+		if err != nil {
+			return time.Time{}, errTimeFormat
+		}
+		nsecs, _ := strconv.ParseInt((frac + "000000000")[:9], 10, 64)
+		if strings.HasPrefix(sec, "-") {
+			nsecs = -nsecs
+		}
+		return time.Unix(secs, nsecs).UTC(), nil
+	}
+	m := relativeTime.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, errTimeFormat
+	}
+	t := now.UTC()
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch m[1] {
+	case "today":
+		t = today
+	case "yesterday":
+		t = today.AddDate(0, 0, -1)
+	case "tomorrow":
+		t = today.AddDate(0, 0, 1)
+	}
+	for _, offset := range timeOffset.FindAllStringSubmatch(m[2], -1) {
+		n, err := strconv.Atoi(offset[2])
+		if err != nil {
+			return time.Time{}, errTimeFormat
+		}
+		if offset[1] == "-" {
+			n = -n
+		}
+		switch offset[3] {
+		case "s":
+			t = t.Add(time.Duration(n) * time.Second)
+		case "m":
+			t = t.Add(time.Duration(n) * time.Minute)
+		case "h":
+			t = t.Add(time.Duration(n) * time.Hour)
+		case "d":
+			t = t.AddDate(0, 0, n)
+		case "w":
+			t = t.AddDate(0, 0, 7*n)
+		case "M":
+			t = t.AddDate(0, n, 0)
+		case "y":
+			t = t.AddDate(n, 0, 0)
+		}
+	}
+	return t, nil
+}
+
+// now return the instant relative times are resolved against, Now or the current time.
+func (flt Option) now() time.Time {
+	if flt.Now.IsZero() {
+		return time.Now()
+	}
+	return flt.Now
+}
+
+// resolveTimes return the filters with the values of the time fields of the struct type t
+// formatted as time.RFC3339Nano, relative times being resolved against now. Values of other
+// fields, and invalid values, are left as is.
+func resolveTimes(t reflect.Type, fs Filters, now time.Time) Filters {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fs
+	}
+	return resolveTimeValues(fs, now, func(param string) bool {
+		_, sf, ok := resolveField(t, param)
+		return ok && kindOf(sf.Type) == kindTime
+	})
+}
+
+// resolveTimeValues return the filters with the values of the fields param for which isTime is
+// true formatted as time.RFC3339Nano, resolved against now.
+func resolveTimeValues(fs Filters, now time.Time, isTime func(param string) bool) Filters {
+	if len(fs) == 0 {
+		return fs
+	}
+	resolved := make(Filters, len(fs))
+	for i, f := range fs {
+		resolved[i] = resolveTime(f, now, isTime)
+	}
+	return resolved
+}
+
+func resolveTime(f Filter, now time.Time, isTime func(param string) bool) Filter {
+	value := func(param, s string) string {
+		if !isTime(param) {
+			return s
+		}
+		tm, err := parseTime(s, now)
+		if err != nil {
+			return s
+		}
+		return tm.Format(time.RFC3339Nano)
+	}
+	values := func(param string, ss []string) []string {
+		resolved := make([]string, len(ss))
+		for i, s := range ss {
+			resolved[i] = value(param, s)
+		}
+		return resolved
+	}
+
+	switch f := f.(type) {
+	case EQ:
+		f.Value = value(f.Param, f.Value)
+		return f
+	case NE:
+		f.Value = value(f.Param, f.Value)
+		return f
+	case GT:
+		f.Value = value(f.Param, f.Value)
+		return f
+	case GTE:
+		f.Value = value(f.Param, f.Value)
+		return f
+	case LT:
+		f.Value = value(f.Param, f.Value)
+		return f
+	case LTE:
+		f.Value = value(f.Param, f.Value)
+		return f
+	case Between:
+		f.From, f.To = value(f.Param, f.From), value(f.Param, f.To)
+		return f
+	case In:
+		f.Values = values(f.Param, f.Values)
+		return f
+	case NotIn:
+		f.Values = values(f.Param, f.Values)
+		return f
+	case And:
+		return And(resolveTimeValues(Filters(f), now, isTime))
+	case Or:
+		return Or(resolveTimeValues(Filters(f), now, isTime))
+	case Not:
+		return Not{resolveTime(f.Filter, now, isTime)}
+	default:
+		return f
+	}
+}
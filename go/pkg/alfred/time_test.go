@@ -0,0 +1,122 @@
+package alfred
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type timeHero struct {
+	Name    string     `filter:"name"`
+	Born    time.Time  `filter:"born"`
+	Retired *time.Time `filter:"retired"`
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 15, 13, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+	tests := map[string]time.Time{
+		"2024-05-01T10:00:00+02:00": time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+		"2024-05-01":                time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"1714521600":                time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"1714521600.25":             time.Date(2024, 5, 1, 0, 0, 0, 250e6, time.UTC),
+		"-86400":                    time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC),
+		"now":                       time.Date(2024, 5, 15, 11, 30, 0, 0, time.UTC),
+		"now-7d":                    time.Date(2024, 5, 8, 11, 30, 0, 0, time.UTC),
+		"now+1h-30m+15s":            time.Date(2024, 5, 15, 12, 0, 15, 0, time.UTC),
+		"today":                     time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC),
+		"today+9h":                  time.Date(2024, 5, 15, 9, 0, 0, 0, time.UTC),
+		"yesterday":                 time.Date(2024, 5, 14, 0, 0, 0, 0, time.UTC),
+		"tomorrow-1w":               time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC),
+		"now-1M":                    time.Date(2024, 4, 15, 11, 30, 0, 0, time.UTC),
+		"now+2y":                    time.Date(2026, 5, 15, 11, 30, 0, 0, time.UTC),
+	}
+	for s, want := range tests {
+		t.Run(s, func(t *testing.T) {
+			got, err := parseTime(s, now)
+			require.NoError(t, err)
+			assert.True(t, want.Equal(got), "want %v, got %v", want, got)
+		})
+	}
+
+	for _, s := range []string{"", "last week", "now-7", "now-7x", "today 9h", "2024-13-01", "1.5e9"} {
+		_, err := parseTime(s, now)
+		assert.ErrorIs(t, err, errTimeFormat, s)
+	}
+}
+
+func TestResolveTimes(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	fs := Filters{
+		GT{"born", "now-7d"},
+		EQ{"name", "today"},
+		Or{Between{"born", "2024-01-01", "today"}, Not{In{"retired", []string{"yesterday", "invalid"}}}},
+		LTE{"unknown", "now"},
+	}
+	assert.Equal(t, Filters{
+		GT{"born", "2024-05-08T12:00:00Z"},
+		EQ{"name", "today"},
+		Or{Between{"born", "2024-01-01T00:00:00Z", "2024-05-15T00:00:00Z"}, Not{In{"retired", []string{"2024-05-14T00:00:00Z", "invalid"}}}},
+		LTE{"unknown", "now"},
+	}, resolveTimes(reflect.TypeFor[timeHero](), fs, now))
+	// the filters are not modified
+	assert.Equal(t, GT{"born", "now-7d"}, fs[0])
+}
+
+func TestRelativeTimes(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	heroes := []timeHero{
+		{Name: "Bruce", Born: now.AddDate(0, 0, -10)},
+		{Name: "Clark", Born: now.AddDate(0, 0, -3), Retired: ptr(now.AddDate(0, 0, -1))},
+		{Name: "Diana", Born: now.Add(-time.Hour)},
+	}
+	opt := Option{Now: now, Filters: Filters{GTE{"born", "now-7d"}, LT{"born", "today"}}, SortBy: "name"}
+
+	page, total, err := Apply(heroes, opt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "Clark", page[0].Name)
+
+	b := &SQLBuilder{Dialect: Postgres{}, Schema: NewSchema(timeHero{})}
+	query := b.Query("SELECT * FROM heroes", opt)
+	assert.Equal(t, `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE "born" >= $1 AND "born" < $2 ORDER BY "name" ASC NULLS FIRST LIMIT ALL OFFSET $3`, query)
+	assert.Equal(t, []any{now.AddDate(0, 0, -7), time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC), 0}, b.Args())
+
+	// without schema, the time fields are not known : values are bound as they are, as strings
+	opt.Filters = append(opt.Filters, GT{"born", "1714557600"}, EQ{"name", "today"})
+	_, args := BuildPSQLQuery("SELECT * FROM heroes", opt)
+	assert.Equal(t, []any{"now-7d", "today", "1714557600", "today", 0}, args)
+
+	// with the schema of the option
+	opt = NewSchema(timeHero{}).ParseURLValues(url.Values{"filter[born][gt]": {"1714557600"}})
+	_, args = BuildPSQLQuery("SELECT * FROM heroes", opt)
+	assert.Equal(t, []any{time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), 0}, args)
+
+	// compiled filters resolve relative times against the given instant
+	c, err := compileFilters(reflect.TypeFor[timeHero](), Filters{GT{"born", "now-2h"}}, now)
+	require.NoError(t, err)
+	for _, hero := range heroes {
+		keep, err := c.Keep(hero)
+		require.NoError(t, err)
+		assert.Equal(t, hero.Name == "Diana", keep, hero.Name)
+	}
+}
+
+func TestKeepFlexibleTimes(t *testing.T) {
+	hero := timeHero{Born: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	for _, f := range []Filter{EQ{"born", "1714557600"}, GT{"born", "2024-05-01"}, LT{"born", "now"}, GTE{"born", "2000-01-01"}} {
+		keep, err := f.Keep(hero)
+		require.NoError(t, err)
+		assert.True(t, keep, f)
+	}
+}
+
+func TestValidateTimes(t *testing.T) {
+	opt := Option{Filters: Filters{GT{"born", "now-7d"}, LT{"born", "2024-05-01"}, EQ{"born", "1714521600"}, LTE{"born", "now-7"}}}
+	assert.Equal(t, ErrValidation{
+		{Param: "born", Op: "lte", Value: "now-7", Reason: "invalid time value, " + errTimeFormat.Error()},
+	}, opt.Validate(NewSchema(timeHero{})))
+}
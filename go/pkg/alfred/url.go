@@ -0,0 +1,206 @@
+package alfred
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// URLValues return the url values of the option, the inverse of ParseURLValues. Parsing the
+// values returns an equivalent option, filters combined together may be reordered. It returns
+// an error if a filter cannot be expressed as url values : filters of unknown types, empty Or
+// groups, lists of values that are empty or contain a comma, fields with other characters than
+// letters, digits, '_', '.' and '-', and Search
+// filters with fields, empty, in a group or after another Search filter.
+func (flt Option) URLValues() (url.Values, error) {
+	values := url.Values{}
+	if flt.Limit != 0 {
+		values.Set("limit", strconv.Itoa(flt.Limit))
+	}
+	if flt.Offset != 0 {
+		values.Set("offset", strconv.Itoa(flt.Offset))
+	}
+	if flt.SortBy != "" {
+		values.Set("sortBy", flt.SortBy)
+	}
+	if flt.Order != "" {
+		values.Set("orderBy", flt.Order)
+	}
+	if flt.Collation != "" {
+		values.Set("collation", flt.Collation)
+	}
+	if flt.Nulls != "" {
+		values.Set("nulls", flt.Nulls)
+	}
+	if len(flt.Cursor) > 0 {
+		values.Set("cursor", encodeCursor(flt.Cursor))
+	}
+	if len(flt.Fields) > 0 {
+		values.Set("fields", strings.Join(flt.Fields, ","))
+	}
+	labels := 0
+	for _, f := range flt.Filters {
+		if search, ok := f.(Search); ok && len(search.Fields) == 0 && search.Value != "" && !values.Has("q") {
+			values.Set("q", search.Value)
+			continue
+		}
+		if err := encodeFilter(values, nil, f, &labels); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// errNotEncodable is the error of a filter which cannot be expressed as url values.
+func errNotEncodable(f Filter, reason string) error {
+	return fmt.Errorf("filter : cannot be encoded as url values, %s (%T)", reason, f)
+}
+
+// encodeFilter add the url values of the filter f, with the segments of prefix before its field.
+// Groups are given unique labels by incrementing labels.
+func encodeFilter(values url.Values, prefix []string, f Filter, labels *int) error {
+	group := func(op string, fs []Filter) error {
+		*labels++
+		for _, sub := range fs {
+			if err := encodeFilter(values, slices.Concat(prefix, []string{op, fmt.Sprintf("g%d", *labels)}), sub, labels); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch f := f.(type) {
+	case And:
+		// an empty And keeps everything, as no filter, but only out of a group or a negation
+		if len(f) == 0 && len(prefix) > 0 {
+			return errNotEncodable(f, "empty group")
+		}
+		return group(groupAND, f)
+	case Or:
+		if len(f) == 0 {
+			return errNotEncodable(f, "empty group")
+		}
+		return group(groupOR, f)
+	case Not:
+		return encodeFilter(values, slices.Concat(prefix, []string{groupNOT}), f.Filter, labels)
+	case Search:
+		// only the first search of the option, without fields, is a 'q' parameter
+		return errNotEncodable(f, "search with fields, empty, in a group or repeated")
+	}
+
+	param, op, value, ok := filterOp(f)
+	if !ok {
+		return errNotEncodable(f, "unsupported filter type")
+	}
+	if !paramName.MatchString(param) {
+		return errNotEncodable(f, "invalid characters in field")
+	}
+	switch op {
+	case "in", "nin", "containsAll", "overlaps", "between":
+		// comma separated values
+		fvs := filterValues(f)
+		if len(fvs) == 0 {
+			return errNotEncodable(f, "empty list")
+		}
+		if slices.ContainsFunc(fvs, func(v string) bool { return strings.Contains(v, ",") }) {
+			return errNotEncodable(f, "comma in list value")
+		}
+	}
+	key := "filter"
+	for _, segment := range slices.Concat(prefix, []string{param, op}) {
+		key += "[" + segment + "]"
+	}
+	values.Add(key, value)
+	return nil
+}
+
+// isOptionParam return true if the url parameter key is a parameter of an Option.
+func isOptionParam(key string) bool {
+	switch key {
+	case "limit", "offset", "sortBy", "orderBy", "cursor", "q", "fields", "collation", "nulls":
+		return true
+	default:
+		return strings.HasPrefix(key, "filter[")
+	}
+}
+
+// URL return a copy of u with the query parameters of the option, in the syntax of the parser of the
+// option parsed by MiddlewareWith, the url values of URLValues otherwise. The other query parameters
+// of u are kept. It returns an error if the option cannot be expressed in this syntax (see Parser).
+func (flt Option) URL(u *url.URL) (*url.URL, error) {
+	parser := flt.parser
+	if parser == nil {
+		parser = Brackets{}
+	}
+	query, err := parser.Encode(u.RawQuery, flt)
+	if err != nil {
+		return nil, err
+	}
+	link := *u
+	link.RawQuery = query
+	return &link, nil
+}
+
+// Links are the URLs of the pages around the current page. A link is empty if there is no such page.
+type Links struct {
+	First string
+	Prev  string
+	Next  string
+	Last  string
+}
+
+// Links return the links of the pages around the page of the option, given the total number of
+// items, as the 'count(*) OVER()' column of AddToPSQLQuery. The links are built from u, see
+// Option.URL. Pages are offset based, the cursor of the option is not kept. It returns an error
+// if the option cannot be expressed in the syntax of its URLs, rather than links to other items.
+func (flt Option) Links(u *url.URL, total int) (Links, error) {
+	flt.Cursor = nil
+	// the pages differ by their offset only
+	if _, err := flt.URL(u); err != nil {
+		return Links{}, err
+	}
+	page := func(offset int) string {
+		opt := flt
+		opt.Offset = offset
+		link, _ := opt.URL(u)
+		return link.String()
+	}
+
+	offset := max(flt.Offset, 0)
+	links := Links{First: page(0), Last: page(0)}
+	if flt.Limit <= 0 {
+		// a single page
+		if offset > 0 {
+			links.Prev = page(0)
+		}
+		return links, nil
+	}
+	if total > 0 {
+		links.Last = page((total - 1) / flt.Limit * flt.Limit)
+	}
+	if offset > 0 {
+		links.Prev = page(max(offset-flt.Limit, 0))
+	}
+	if offset+flt.Limit < total {
+		links.Next = page(offset + flt.Limit)
+	}
+	return links, nil
+}
+
+// Header return the links as the value of a Link HTTP header (RFC 8288) :
+// <url>; rel="first", <url>; rel="prev", <url>; rel="next", <url>; rel="last".
+func (l Links) Header() string {
+	var links []string
+	for _, link := range []struct{ rel, url string }{
+		{"first", l.First},
+		{"prev", l.Prev},
+		{"next", l.Next},
+		{"last", l.Last},
+	} {
+		if link.url != "" {
+			links = append(links, fmt.Sprintf("<%s>; rel=%q", link.url, link.rel))
+		}
+	}
+	return strings.Join(links, ", ")
+}
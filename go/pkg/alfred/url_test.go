@@ -0,0 +1,198 @@
+package alfred
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLValues(t *testing.T) {
+	tests := map[string]struct {
+		opt      Option
+		expected url.Values
+	}{
+		"empty": {opt: Option{}, expected: url.Values{}},
+		"page": {
+			opt:      Option{Limit: 10, Offset: 20, SortBy: "team,-name", Order: "desc"},
+			expected: url.Values{"limit": {"10"}, "offset": {"20"}, "sortBy": {"team,-name"}, "orderBy": {"desc"}},
+		},
+		"cursor": {
+			opt:      Option{Limit: 10, SortBy: "name,id", Cursor: []string{"Bruce", "1"}},
+			expected: url.Values{"limit": {"10"}, "sortBy": {"name,id"}, "cursor": {encodeCursor([]string{"Bruce", "1"})}},
+		},
+		"filters": {
+			opt: Option{Filters: Filters{
+				Between{"age", "18", "30"},
+				In{"name", []string{"Bruce", "Clark"}},
+				EQ{"name", "Diana"},
+				ContainsAll{"tags", []string{"rich", "smart"}},
+			}},
+			expected: url.Values{
+				"filter[age][between]":      {"18,30"},
+				"filter[name][in]":          {"Bruce,Clark"},
+				"filter[name][eq]":          {"Diana"},
+				"filter[tags][containsAll]": {"rich,smart"},
+			},
+		},
+		"logic": {
+			opt: Option{Filters: Filters{
+				Not{EQ{"status", "archived"}},
+				Or{Like{"name", "bat"}, And{GT{"age", "18"}, Not{Like{"name", "super"}}}},
+				Not{Or{EQ{"team", "jla"}}},
+			}},
+			expected: url.Values{
+				"filter[not][status][eq]":                  {"archived"},
+				"filter[or][g1][name][like]":               {"bat"},
+				"filter[or][g1][and][g2][age][gt]":         {"18"},
+				"filter[or][g1][and][g2][not][name][like]": {"super"},
+				"filter[not][or][g3][team][eq]":            {"jla"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			values, err := tt.opt.URLValues()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+func TestURLValuesNotEncodable(t *testing.T) {
+	tests := map[string]struct {
+		filter Filter
+		err    string
+	}{
+		"comma":        {filter: In{"name", []string{"Wayne, Bruce"}}, err: "comma in list value (alfred.In)"},
+		"comma-range":  {filter: Between{"age", "", "1,5"}, err: "comma in list value (alfred.Between)"},
+		"empty-list":   {filter: NotIn{"name", nil}, err: "empty list (alfred.NotIn)"},
+		"empty-or":     {filter: Or{}, err: "empty group (alfred.Or)"},
+		"nested":       {filter: And{EQ{"age", "1"}, Not{Or{}}}, err: "empty group (alfred.Or)"},
+		"not-and":      {filter: Not{And{}}, err: "empty group (alfred.And)"},
+		"or-and":       {filter: Or{And{}, EQ{"age", "1"}}, err: "empty group (alfred.And)"},
+		"seek":         {filter: Seek{Keys: []SortKey{{Field: "name"}}, Values: []string{"Bruce"}}, err: "unsupported filter type (alfred.Seek)"},
+		"brackets":     {filter: EQ{"name]", "x"}, err: "invalid characters in field (alfred.EQ)"},
+		"space":        {filter: EQ{"first name", "x"}, err: "invalid characters in field (alfred.EQ)"},
+		"search":       {filter: Search{Value: "bat", Fields: []string{"name"}}, err: "search with fields, empty, in a group or repeated (alfred.Search)"},
+		"search-group": {filter: Or{Search{Value: "bat"}}, err: "search with fields, empty, in a group or repeated (alfred.Search)"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Option{Filters: Filters{Like{"name", "a,b"}, tt.filter}}.URLValues()
+			assert.EqualError(t, err, "filter : cannot be encoded as url values, "+tt.err)
+		})
+	}
+	_, err := Option{Filters: Filters{Search{Value: "bat"}, Search{Value: "man"}}}.URLValues()
+	assert.Error(t, err)
+
+	// an empty And keeps everything, out of a group or a negation
+	values, err := Option{Filters: Filters{And{}}}.URLValues()
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{}, values)
+}
+
+func TestURLValuesRoundTrip(t *testing.T) {
+	// filters in the order of the parsed url values
+	tests := map[string]Option{
+		"page":   {Limit: 5, Offset: 10, SortBy: "-age", Order: "asc"},
+		"cursor": {Limit: 5, SortBy: "name,id", Cursor: []string{"Bruce", "1"}},
+		"filters": {Filters: Filters{
+			Between{"age", "18", "30"},
+			ContainsAll{"tags", []string{"rich", "smart"}},
+			In{"team", []string{"jla", "jsa"}},
+			Like{"team", "a b&c=d"},
+		}},
+		"logic": {Filters: Filters{
+			EQ{"age", "42"},
+			Not{And{GTE{"age", "18"}, LT{"age", "30"}}},
+			Not{Regex{"name", "^b"}},
+			Or{Like{"name", "bat"}, Not{In{"team", []string{"jla", "jsa"}}}},
+		}},
+	}
+	for name, opt := range tests {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := opt.URLValues()
+			require.NoError(t, err)
+			values, err := url.ParseQuery(encoded.Encode())
+			assert.NoError(t, err)
+			assert.Equal(t, opt, ParseURLValues(values))
+		})
+	}
+}
+
+func TestOptionURL(t *testing.T) {
+	u, _ := url.Parse("https://api.example.com/heroes?api_key=secret&limit=3&filter[name][eq]=Bruce")
+	opt := Option{Limit: 10, Filters: Filters{EQ{"team", "jla"}}}
+	link, err := opt.URL(u)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/heroes?api_key=secret&filter%5Bteam%5D%5Beq%5D=jla&limit=10", link.String())
+	// u is not modified
+	assert.Equal(t, "api_key=secret&limit=3&filter[name][eq]=Bruce", u.RawQuery)
+}
+
+func TestLinks(t *testing.T) {
+	u, _ := url.Parse("/heroes?sortBy=name")
+	link := func(query string) string { return "/heroes?" + query }
+	tests := map[string]struct {
+		opt      Option
+		total    int
+		expected Links
+	}{
+		"first": {
+			opt:   Option{Limit: 10, SortBy: "name"},
+			total: 25,
+			expected: Links{
+				First: link("limit=10&sortBy=name"),
+				Next:  link("limit=10&offset=10&sortBy=name"),
+				Last:  link("limit=10&offset=20&sortBy=name"),
+			},
+		},
+		"middle": {
+			opt:   Option{Limit: 10, Offset: 5, SortBy: "name"},
+			total: 25,
+			expected: Links{
+				First: link("limit=10&sortBy=name"),
+				Prev:  link("limit=10&sortBy=name"),
+				Next:  link("limit=10&offset=15&sortBy=name"),
+				Last:  link("limit=10&offset=20&sortBy=name"),
+			},
+		},
+		"last": {
+			opt:   Option{Limit: 10, Offset: 20, SortBy: "name"},
+			total: 20,
+			expected: Links{
+				First: link("limit=10&sortBy=name"),
+				Prev:  link("limit=10&offset=10&sortBy=name"),
+				Last:  link("limit=10&offset=10&sortBy=name"),
+			},
+		},
+		"empty": {
+			opt:      Option{Limit: 10, SortBy: "name"},
+			total:    0,
+			expected: Links{First: link("limit=10&sortBy=name"), Last: link("limit=10&sortBy=name")},
+		},
+		"no-limit": {
+			opt:      Option{Offset: 3, SortBy: "name", Cursor: []string{"Bruce"}},
+			total:    25,
+			expected: Links{First: link("sortBy=name"), Prev: link("sortBy=name"), Last: link("sortBy=name")},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			links, err := tt.opt.Links(u, tt.total)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, links)
+		})
+	}
+
+	_, err := Option{Limit: 10, Filters: Filters{In{"name", []string{"Wayne, Bruce"}}}}.Links(u, 25)
+	assert.Error(t, err)
+}
+
+func TestLinksHeader(t *testing.T) {
+	links := Links{First: "/heroes?limit=10", Next: "/heroes?limit=10&offset=10", Last: "/heroes?limit=10&offset=20"}
+	assert.Equal(t, `</heroes?limit=10>; rel="first", </heroes?limit=10&offset=10>; rel="next", </heroes?limit=10&offset=20>; rel="last"`, links.Header())
+	assert.Equal(t, "", Links{}.Header())
+}
@@ -0,0 +1,319 @@
+package alfred
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// operatorKinds are the kinds of fields supported by each filter operator.
+var operatorKinds = map[string][]valueKind{
+	"like":        {kindString},
+	"eq":          {kindString, kindInt, kindUint, kindFloat, kindBool, kindTime, kindDuration},
+	"ne":          {kindString, kindInt, kindUint, kindFloat, kindBool, kindTime, kindDuration},
+	"gt":          {kindInt, kindUint, kindFloat, kindTime, kindDuration},
+	"gte":         {kindInt, kindUint, kindFloat, kindTime, kindDuration},
+	"lt":          {kindInt, kindUint, kindFloat, kindTime, kindDuration},
+	"lte":         {kindInt, kindUint, kindFloat, kindTime, kindDuration},
+	"contain":     {kindString, kindInt, kindUint, kindFloat, kindList},
+	"containsAll": {kindString, kindList},
+	"overlaps":    {kindString, kindList},
+	"in":          {kindString, kindInt, kindUint, kindFloat, kindBool, kindTime, kindDuration},
+	"nin":         {kindString, kindInt, kindUint, kindFloat, kindBool, kindTime, kindDuration},
+	"between":     {kindInt, kindUint, kindFloat, kindTime, kindDuration},
+	"isnull":      {kindUnsupported, kindString, kindInt, kindUint, kindFloat, kindBool, kindTime, kindDuration, kindList},
+	"startswith":  {kindString},
+	"regex":       {kindString},
+}
+
+// filterOp return the field, the operator and the value of a simple filter.
+func filterOp(f Filter) (param, op, value string, ok bool) {
+	switch f := f.(type) {
+	case Like:
+		return f.Param, "like", f.Value, true
+	case EQ:
+		return f.Param, "eq", f.Value, true
+	case GT:
+		return f.Param, "gt", f.Value, true
+	case GTE:
+		return f.Param, "gte", f.Value, true
+	case LT:
+		return f.Param, "lt", f.Value, true
+	case LTE:
+		return f.Param, "lte", f.Value, true
+	case Contain:
+		return f.Param, "contain", f.Value, true
+	case ContainsAll:
+		return f.Param, "containsAll", strings.Join(f.Values, ","), true
+	case Overlaps:
+		return f.Param, "overlaps", strings.Join(f.Values, ","), true
+	case NE:
+		return f.Param, "ne", f.Value, true
+	case In:
+		return f.Param, "in", strings.Join(f.Values, ","), true
+	case NotIn:
+		return f.Param, "nin", strings.Join(f.Values, ","), true
+	case Between:
+		return f.Param, "between", f.From + "," + f.To, true
+	case IsNull:
+		return f.Param, "isnull", f.Value, true
+	case StartsWith:
+		return f.Param, "startswith", f.Value, true
+	case Regex:
+		return f.Param, "regex", f.Value, true
+	default:
+		return "", "", "", false
+	}
+}
+
+// filterValues return the values of a simple filter.
+func filterValues(f Filter) []string {
+	switch f := f.(type) {
+	case In:
+		return f.Values
+	case NotIn:
+		return f.Values
+	case ContainsAll:
+		return f.Values
+	case Overlaps:
+		return f.Values
+	case Between:
+		return []string{f.From, f.To}
+	default:
+		_, _, value, _ := filterOp(f)
+		return []string{value}
+	}
+}
+
+// Validate check the option against the fields of schema. It rejects unknown fields,
+// operators not supported by the type of a field or not allowed by its tag, sorting on fields
+// not declared sortable (see NewSchema), values that cannot be parsed as the
+// type of their field, invalid order directions, negative pagination values and cursors not
+// matching the sort keys, as well as the invalid parameters dropped by ParseURLValues.
+// A nil schema only checks the parameters which do not depend on the fields.
+// The returned error is an ErrValidation listing all the invalid parameters.
+func (flt Option) Validate(schema *Schema) error {
+	errs := slices.Clone(flt.errs)
+
+	if flt.Limit < 0 {
+		errs = append(errs, ErrInvalidParam{Param: "limit", Value: strconv.Itoa(flt.Limit), Reason: "must be positive"})
+	}
+	if flt.Offset < 0 {
+		errs = append(errs, ErrInvalidParam{Param: "offset", Value: strconv.Itoa(flt.Offset), Reason: "must be positive"})
+	}
+
+	switch strings.ToLower(strings.TrimSpace(flt.Order)) {
+	case "", orderASC, orderDESC:
+	default:
+		errs = append(errs, ErrInvalidParam{Param: "orderBy", Value: flt.Order, Reason: "invalid order direction, must be asc or desc"})
+	}
+	if flt.Collation != "" {
+		if _, err := newCollator(flt.Collation); err != nil {
+			errs = append(errs, ErrInvalidParam{Param: "collation", Value: flt.Collation, Reason: "invalid collation, must be a BCP 47 language tag"})
+		}
+	}
+	if flt.Nulls != "" && flt.nulls() == "" {
+		errs = append(errs, ErrInvalidParam{Param: "nulls", Value: flt.Nulls, Reason: "invalid nulls ordering, must be first or last"})
+	}
+	if schema != nil {
+		for _, key := range flt.SortKeys() {
+			f, ok := schema.field(key.Field)
+			switch {
+			case !ok:
+				errs = append(errs, ErrInvalidParam{Param: "sortBy", Value: key.Field, Reason: "unknown field"})
+			case f.kind == kindUnsupported || f.kind == kindList:
+				errs = append(errs, ErrInvalidParam{Param: "sortBy", Value: key.Field, Reason: "field cannot be sorted"})
+			case !f.sortable:
+				errs = append(errs, ErrInvalidParam{Param: "sortBy", Value: key.Field, Reason: "sorting not allowed"})
+			}
+		}
+
+		for _, f := range flt.Filters {
+			errs = append(errs, validateFilter(schema, f)...)
+		}
+		for _, field := range flt.Fields {
+			if _, ok := schema.field(field); !ok {
+				errs = append(errs, ErrInvalidParam{Param: "fields", Value: field, Reason: "unknown field"})
+			}
+		}
+	}
+
+	if len(flt.Cursor) > 0 {
+		keys := flt.SortKeys()
+		if len(keys) != len(flt.Cursor) {
+			errs = append(errs, ErrInvalidParam{Param: "cursor", Value: encodeCursor(flt.Cursor), Reason: "cursor does not match sort keys"})
+		} else if schema != nil {
+			for i, key := range keys {
+				f, ok := schema.field(key.Field)
+				if !ok || f.kind == kindUnsupported || f.kind == kindList {
+					continue
+				}
+				if err := parseKind(f.kind, flt.Cursor[i]); err != nil {
+					errs = append(errs, ErrInvalidParam{Param: "cursor", Value: encodeCursor(flt.Cursor), Reason: err.Error()})
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateFilter(schema *Schema, f Filter) ErrValidation {
+	var errs ErrValidation
+	switch f := f.(type) {
+	case And:
+		for _, sub := range f {
+			errs = append(errs, validateFilter(schema, sub)...)
+		}
+		return errs
+	case Or:
+		for _, sub := range f {
+			errs = append(errs, validateFilter(schema, sub)...)
+		}
+		return errs
+	case Not:
+		return validateFilter(schema, f.Filter)
+	case Search:
+		return validateSearch(schema, f)
+	}
+
+	param, op, value, ok := filterOp(f)
+	if !ok {
+		return nil
+	}
+	field, ok := schema.field(param)
+	if !ok {
+		return ErrValidation{{Param: param, Op: op, Value: value, Reason: "unknown field"}}
+	}
+	if !field.tag.allows(op) {
+		return ErrValidation{{Param: param, Op: op, Value: value, Reason: "operator not allowed"}}
+	}
+	if !slices.Contains(operatorKinds[op], field.kind) {
+		return ErrValidation{{Param: param, Op: op, Value: value, Reason: fmt.Sprintf("operator not supported on %v field", field.kind)}}
+	}
+	kind := field.kind
+	if kind == kindList {
+		// values are elements of the list
+		if field.elem == kindUnsupported || field.elem == kindList {
+			return ErrValidation{{Param: param, Op: op, Value: value, Reason: "list elements of unsupported type"}}
+		}
+		kind = field.elem
+	}
+	if err := validateValue(op, kind, value); err != nil {
+		return ErrValidation{{Param: param, Op: op, Value: value, Reason: err.Error()}}
+	}
+	return nil
+}
+
+func validateSearch(schema *Schema, f Search) ErrValidation {
+	if len(f.Fields) == 0 {
+		if len(searchFields(schema.typ)) == 0 {
+			return ErrValidation{{Param: "q", Value: f.Value, Reason: "no searchable fields"}}
+		}
+		return nil
+	}
+	var errs ErrValidation
+	for _, name := range f.Fields {
+		field, ok := schema.field(name)
+		switch {
+		case !ok:
+			errs = append(errs, ErrInvalidParam{Param: "q", Value: name, Reason: "unknown field"})
+		case field.kind != kindString:
+			errs = append(errs, ErrInvalidParam{Param: "q", Value: name, Reason: fmt.Sprintf("search not supported on %v field", field.kind)})
+		}
+	}
+	return errs
+}
+
+// validateValue check that value is a valid value of the operator op for a field of kind k.
+func validateValue(op string, k valueKind, value string) error {
+	switch op {
+	case "like", "startswith":
+		return nil
+	case "regex":
+		if _, err := regexp.Compile(value); err != nil {
+			return errors.New("invalid regular expression")
+		}
+		return nil
+	case "isnull":
+		return parseKind(kindBool, value)
+	case "in", "nin", "containsAll", "overlaps":
+		for _, v := range strings.Split(value, ",") {
+			if err := parseKind(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "between":
+		from, to, ok := strings.Cut(value, ",")
+		if !ok || strings.Contains(to, ",") {
+			return errors.New("between requires two comma separated values")
+		}
+		if err := parseKind(k, from); err != nil {
+			return err
+		}
+		return parseKind(k, to)
+	default:
+		return parseKind(k, value)
+	}
+}
+
+// parseKind check that value can be parsed as a value of kind k.
+func parseKind(k valueKind, value string) error {
+	var err error
+	switch k {
+	case kindInt:
+		_, err = strconv.ParseInt(value, 10, 0)
+	case kindUint:
+		_, err = strconv.ParseUint(value, 10, 0)
+	case kindFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case kindBool:
+		_, err = strconv.ParseBool(value)
+	case kindTime:
+		if _, err = parseTime(value, time.Now()); err != nil {
+			return fmt.Errorf("invalid time value, %w", err)
+		}
+	case kindDuration:
+		if _, err = time.ParseDuration(value); err != nil {
+			return errors.New("invalid duration value, supported format time.ParseDuration")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %v value", k)
+	}
+	return nil
+}
+
+// An ErrInvalidParam describes an invalid parameter of an Option.
+type ErrInvalidParam struct {
+	Param  string `json:"param"`
+	Op     string `json:"op,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e ErrInvalidParam) Error() string {
+	if e.Op != "" {
+		return fmt.Sprintf("filter: field '%v' : operator '%v' : %v", e.Param, e.Op, e.Reason)
+	}
+	return fmt.Sprintf("filter: parameter '%v' : %v", e.Param, e.Reason)
+}
+
+// An ErrValidation is returned by Option.Validate with all the invalid parameters of an Option.
+type ErrValidation []ErrInvalidParam
+
+func (e ErrValidation) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
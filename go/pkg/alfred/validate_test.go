@@ -0,0 +1,162 @@
+package alfred
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateHero struct {
+	Name    string    `filter:"name"`
+	Age     int       `filter:"age"`
+	Score   float64   `filter:"score"`
+	Alive   bool      `filter:"alive"`
+	Birth   time.Time `filter:"birth"`
+	Friends []string  `filter:"friends"`
+}
+
+func TestValidate(t *testing.T) {
+	schema := NewSchema(validateHero{})
+	tests := map[string]struct {
+		opt  Option
+		want error
+	}{
+		"empty": {opt: Option{}, want: nil},
+		"valid": {
+			opt: Option{
+				Limit:   10,
+				SortBy:  "name,-Age",
+				Order:   "DESC",
+				Filters: Filters{Like{"name", "bat"}, Or{GT{"age", "18"}, Not{EQ{"alive", "true"}}}, LTE{"birth", "2006-05-01T00:00:00Z"}},
+			},
+			want: nil,
+		},
+		"pagination": {
+			opt: Option{Limit: -1, Offset: -2},
+			want: ErrValidation{
+				{Param: "limit", Value: "-1", Reason: "must be positive"},
+				{Param: "offset", Value: "-2", Reason: "must be positive"},
+			},
+		},
+		"order": {
+			opt:  Option{SortBy: "name", Order: "asc; DROP TABLE heroes"},
+			want: ErrValidation{{Param: "orderBy", Value: "asc; DROP TABLE heroes", Reason: "invalid order direction, must be asc or desc"}},
+		},
+		"sort-field": {
+			opt: Option{SortBy: "name,power,friends"},
+			want: ErrValidation{
+				{Param: "sortBy", Value: "power", Reason: "unknown field"},
+				{Param: "sortBy", Value: "friends", Reason: "field cannot be sorted"},
+			},
+		},
+		"unknown-field": {
+			opt:  Option{Filters: Filters{Or{EQ{"power", "flight"}}}},
+			want: ErrValidation{{Param: "power", Op: "eq", Value: "flight", Reason: "unknown field"}},
+		},
+		"unsupported-operator": {
+			opt: Option{Filters: Filters{Like{"age", "4"}, GT{"alive", "true"}}},
+			want: ErrValidation{
+				{Param: "age", Op: "like", Value: "4", Reason: "operator not supported on int field"},
+				{Param: "alive", Op: "gt", Value: "true", Reason: "operator not supported on bool field"},
+			},
+		},
+		"invalid-value": {
+			opt: Option{Filters: Filters{GT{"age", "old"}, Not{LT{"score", "x"}}, EQ{"birth", "last week"}}},
+			want: ErrValidation{
+				{Param: "age", Op: "gt", Value: "old", Reason: "invalid int value"},
+				{Param: "score", Op: "lt", Value: "x", Reason: "invalid float value"},
+				{Param: "birth", Op: "eq", Value: "last week", Reason: "invalid time value, " + errTimeFormat.Error()},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.opt.Validate(schema))
+		})
+	}
+}
+
+func TestValidateParsed(t *testing.T) {
+	values, err := url.ParseQuery("limit=ten&offset=1&filter[name][is]=bruce&filter[name]=bruce&filter[xor][g][name][eq]=bruce")
+	assert.NoError(t, err)
+	opt := ParseURLValues(values)
+	assert.Equal(t, ErrValidation{
+		{Param: "limit", Value: "ten", Reason: "invalid integer"},
+		{Param: "filter[name]", Value: "bruce", Reason: "invalid filter"},
+		{Param: "name", Op: "is", Value: "bruce", Reason: "unknown operator"},
+		{Param: "filter[xor][g][name][eq]", Value: "bruce", Reason: "invalid filter"},
+	}, opt.Validate(NewSchema(&validateHero{})))
+}
+
+func TestValidateWithoutSchema(t *testing.T) {
+	opt := Option{
+		Limit:   -1,
+		Order:   "up",
+		SortBy:  "power",
+		Cursor:  []string{"flight", "1"},
+		Filters: Filters{EQ{"power", "flight"}},
+		Fields:  []string{"power"},
+	}
+	assert.Equal(t, ErrValidation{
+		{Param: "limit", Value: "-1", Reason: "must be positive"},
+		{Param: "orderBy", Value: "up", Reason: "invalid order direction, must be asc or desc"},
+		{Param: "cursor", Value: encodeCursor(opt.Cursor), Reason: "cursor does not match sort keys"},
+	}, opt.Validate(nil))
+	assert.NoError(t, Option{SortBy: "power", Filters: Filters{EQ{"power", "flight"}}}.Validate(nil))
+}
+
+func TestErrValidation(t *testing.T) {
+	err := ErrValidation{
+		{Param: "age", Op: "gt", Value: "old", Reason: "invalid int value"},
+		{Param: "orderBy", Value: "up", Reason: "invalid order direction, must be asc or desc"},
+	}
+	assert.Equal(t, "filter: field 'age' : operator 'gt' : invalid int value; filter: parameter 'orderBy' : invalid order direction, must be asc or desc", err.Error())
+}
+
+func TestValidatePermissions(t *testing.T) {
+	opt := Option{
+		SortBy:  "name,-secret,address.zip",
+		Filters: Filters{EQ{"name", "Bruce"}, Or{GTE{"age", "18"}, Not{EQ{"team", "jla"}}}, Regex{"secret", "^bat"}},
+	}
+	assert.Equal(t, ErrValidation{
+		{Param: "sortBy", Value: "secret", Reason: "sorting not allowed"},
+		{Param: "sortBy", Value: "address.zip", Reason: "sorting not allowed"},
+		{Param: "age", Op: "gte", Value: "18", Reason: "operator not allowed"},
+		{Param: "team", Op: "eq", Value: "jla", Reason: "operator not allowed"},
+	}, opt.Validate(NewSchema(tagHero{})))
+}
+
+func TestSchemaParseURLValues(t *testing.T) {
+	values, err := url.ParseQuery("sortBy=-name,secret,power&filter[name][like]=bat&filter[age][gte]=18" +
+		"&filter[or][g][team][eq]=jla&filter[or][g][age][lt]=40&filter[not][team][in]=jla,jsa&filter[power][eq]=flight")
+	require.NoError(t, err)
+	schema := NewSchema(tagHero{})
+	opt := schema.ParseURLValues(values)
+
+	assert.Equal(t, "-name,power", opt.SortBy)
+	// the group holding a filter not allowed is left out as a whole
+	assert.ElementsMatch(t, Filters{Like{"name", "bat"}, EQ{"power", "flight"}}, opt.Filters)
+	assert.ElementsMatch(t, ErrValidation{
+		{Param: "sortBy", Value: "secret", Reason: "sorting not allowed"},
+		{Param: "age", Op: "gte", Value: "18", Reason: "operator not allowed"},
+		{Param: "team", Op: "eq", Value: "jla", Reason: "operator not allowed"},
+		{Param: "team", Op: "in", Value: "jla,jsa", Reason: "operator not allowed"},
+		{Param: "power", Op: "eq", Value: "flight", Reason: "unknown field"},
+		{Param: "sortBy", Value: "power", Reason: "unknown field"},
+	}, opt.Validate(schema))
+
+	// the SQL query of the option maps the fields to their columns
+	query := AddToPSQLQuery("SELECT * FROM heroes", Option{SortBy: opt.SortBy, Filters: opt.Filters[:1], schema: opt.schema})
+	assert.Equal(t, `SELECT *, count(*) OVER() FROM (SELECT * FROM heroes) AS query WHERE "hero_name" ILIKE '%bat%' ESCAPE '\' ORDER BY "hero_name" DESC NULLS LAST, "power" ASC NULLS FIRST LIMIT ALL OFFSET 0`, query)
+
+	// the builders do not prune the groups either, and match nothing rather than leaving them out
+	b := &SQLBuilder{Schema: schema}
+	where := b.where(Filters{Or{EQ{"name", "Bruce"}, GTE{"age", "18"}}, Not{And{Like{"name", "bat"}, EQ{"team", "jla"}}}})
+	assert.Equal(t, "WHERE FALSE AND FALSE", where)
+	mongo, err := (&MongoBuilder{Schema: schema}).Filter(And(storeSchema{schema}.filters(Option{Filters: Filters{Not{Or{Like{"name", "bat"}, EQ{"team", "jla"}}}}})))
+	require.NoError(t, err)
+	assert.Equal(t, mongoFalse, mongo)
+}
@@ -0,0 +1,161 @@
+package alfred
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// FilterValuer is implemented by types filtered and sorted as the value returned by FilterValue :
+// a string, an integer, a float, a bool, a time.Time, a time.Duration or a slice, or nil for a null value.
+// The kind of the type is the kind of the value returned by its zero value, which must not be nil.
+type FilterValuer interface {
+	FilterValue() any
+}
+
+var (
+	valuerType   = reflect.TypeFor[FilterValuer]()
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+)
+
+// scalarFunc return the value compared by filters and sorting for a value of some type,
+// and false if the value is null.
+type scalarFunc func(v reflect.Value) (reflect.Value, bool)
+
+type scalarInfo struct {
+	kind   valueKind
+	scalar scalarFunc
+	// elements of a list
+	elem *scalarInfo
+}
+
+// scalarInfos caches the results of scalarOf.
+var scalarInfos sync.Map // reflect.Type -> scalarInfo
+
+// scalarOf return the kind of the values of type t, and the function returning the value
+// compared for a value of type t. Pointers are dereferenced, a nil pointer is null. Types
+// implementing FilterValuer are compared as the value returned by FilterValue. Structs of a
+// value and a 'Valid' bool field, as sql.NullString or sql.Null[T], are null when not valid.
+// Slices and arrays are lists of the values of their elements.
+func scalarOf(t reflect.Type) scalarInfo {
+	if info, ok := scalarInfos.Load(t); ok {
+		return info.(scalarInfo)
+	}
+	info := newScalarInfo(t)
+	scalarInfos.Store(t, info)
+	return info
+}
+
+func newScalarInfo(t reflect.Type) scalarInfo {
+	switch {
+	case t.Kind() == reflect.Pointer:
+		elem := scalarOf(t.Elem())
+		return scalarInfo{kind: elem.kind, elem: elem.elem, scalar: func(v reflect.Value) (reflect.Value, bool) {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			return elem.scalar(v.Elem())
+		}}
+	case t.Implements(valuerType):
+		info := valuerInfo(t)
+		info.scalar = func(v reflect.Value) (reflect.Value, bool) {
+			return valuerScalar(v.Interface().(FilterValuer))
+		}
+		return info
+	case reflect.PointerTo(t).Implements(valuerType):
+		info := valuerInfo(t)
+		info.scalar = func(v reflect.Value) (reflect.Value, bool) {
+			if !v.CanAddr() {
+				p := reflect.New(t)
+				p.Elem().Set(v)
+				v = p.Elem()
+			}
+			return valuerScalar(v.Addr().Interface().(FilterValuer))
+		}
+		return info
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		elem := scalarOf(t.Elem())
+		return scalarInfo{kind: kindList, elem: &elem, scalar: func(v reflect.Value) (reflect.Value, bool) {
+			if isNil(v) {
+				return reflect.Value{}, false
+			}
+			return v, true
+		}}
+	}
+	if value, valid, ok := nullFields(t); ok {
+		elem := scalarOf(t.Field(value).Type)
+		return scalarInfo{kind: elem.kind, elem: elem.elem, scalar: func(v reflect.Value) (reflect.Value, bool) {
+			if !v.Field(valid).Bool() {
+				return reflect.Value{}, false
+			}
+			return elem.scalar(v.Field(value))
+		}}
+	}
+	return scalarInfo{kind: basicKind(t), scalar: func(v reflect.Value) (reflect.Value, bool) { return v, true }}
+}
+
+// valuerInfo return the kind of the value returned by FilterValue on the zero value of t.
+func valuerInfo(t reflect.Type) scalarInfo {
+	x := reflect.New(t).Interface().(FilterValuer).FilterValue()
+	if x == nil || reflect.TypeOf(x).Implements(valuerType) {
+		return scalarInfo{kind: kindUnsupported}
+	}
+	return scalarOf(reflect.TypeOf(x))
+}
+
+// valuerScalar return the value compared for the value returned by fv.
+func valuerScalar(fv FilterValuer) (reflect.Value, bool) {
+	x := fv.FilterValue()
+	if x == nil {
+		return reflect.Value{}, false
+	}
+	v := reflect.ValueOf(x)
+	if v.Type().Implements(valuerType) {
+		return v, true
+	}
+	return scalarOf(v.Type()).scalar(v)
+}
+
+// nullFields return the index of the value field and of the 'Valid' field of a nullable struct type t.
+func nullFields(t reflect.Type) (value, valid int, ok bool) {
+	if t.Kind() != reflect.Struct || t == timeType || t.NumField() != 2 {
+		return 0, 0, false
+	}
+	for i := 0; i < 2; i++ {
+		sf := t.Field(i)
+		if sf.Name == "Valid" && sf.Type.Kind() == reflect.Bool && t.Field(1-i).IsExported() {
+			return 1 - i, i, true
+		}
+	}
+	return 0, 0, false
+}
+
+// basicKind return the kind of values of type t, from its underlying type.
+func basicKind(t reflect.Type) valueKind {
+	switch t {
+	case timeType:
+		return kindTime
+	case durationType:
+		return kindDuration
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return kindString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return kindInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return kindUint
+	case reflect.Float32, reflect.Float64:
+		return kindFloat
+	case reflect.Bool:
+		return kindBool
+	default:
+		return kindUnsupported
+	}
+}
+
+// scalar return the value compared for the value v, and false if v is null.
+func scalar(v reflect.Value) (reflect.Value, bool) {
+	return scalarOf(v.Type()).scalar(v)
+}
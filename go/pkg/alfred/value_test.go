@@ -0,0 +1,198 @@
+package alfred
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type valueStatus string
+
+// valueRank is filtered and sorted by its level, a rank without name is null.
+type valueRank struct {
+	name  string
+	level int
+}
+
+func (r valueRank) FilterValue() any {
+	if r.name == "" && r.level != 0 {
+		return nil
+	}
+	return r.level
+}
+
+// valueCode is filtered and sorted as an upper case string.
+type valueCode string
+
+func (c *valueCode) FilterValue() any {
+	return strings.ToUpper(string(*c))
+}
+
+type valueHero struct {
+	Name     string         `filter:"name"`
+	Nickname *string        `filter:"nickname"`
+	Age      *int           `filter:"age"`
+	Power    uint64         `filter:"power"`
+	City     sql.NullString `filter:"city"`
+	Rating   sql.Null[float64]
+	Since    sql.NullTime  `filter:"since"`
+	Cooldown time.Duration `filter:"cooldown"`
+	Status   valueStatus   `filter:"status"`
+	Rank     valueRank     `filter:"rank"`
+	Code     valueCode     `filter:"code"`
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var valueHeroes = []valueHero{
+	{
+		Name:     "bruce",
+		Nickname: ptr("Batman"),
+		Age:      ptr(42),
+		Power:    18446744073709551615,
+		City:     sql.NullString{String: "Gotham", Valid: true},
+		Rating:   sql.Null[float64]{V: 4.5, Valid: true},
+		Since:    sql.NullTime{Time: time.Date(1939, time.May, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		Cooldown: time.Hour,
+		Status:   "active",
+		Rank:     valueRank{name: "knight", level: 3},
+		Code:     "bw",
+	},
+	{
+		Name:     "clark",
+		Nickname: ptr("Superman"),
+		Power:    100,
+		Since:    sql.NullTime{Time: time.Date(1938, time.June, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		Cooldown: 90 * time.Second,
+		Status:   "retired",
+		Rank:     valueRank{level: 1},
+		Code:     "ck",
+	},
+	{
+		Name:     "diana",
+		Age:      ptr(5000),
+		City:     sql.NullString{String: "Themyscira", Valid: true},
+		Rating:   sql.Null[float64]{V: 5, Valid: true},
+		Cooldown: 2 * time.Hour,
+		Status:   "active",
+		Code:     "DP",
+	},
+}
+
+func TestKeepValues(t *testing.T) {
+	tests := map[string]struct {
+		filter   Filter
+		expected []string
+	}{
+		"pointer":          {EQ{"nickname", "Batman"}, []string{"bruce"}},
+		"pointer-like":     {Like{"nickname", "man"}, []string{"bruce", "clark"}},
+		"pointer-nil":      {GT{"age", "10"}, []string{"bruce", "diana"}},
+		"pointer-ne":       {NE{"nickname", "Batman"}, []string{"clark"}},
+		"pointer-isnull":   {IsNull{"age", "true"}, []string{"clark"}},
+		"uint":             {GT{"power", "1000"}, []string{"bruce"}},
+		"uint-max":         {EQ{"power", "18446744073709551615"}, []string{"bruce"}},
+		"uint-in":          {In{"power", []string{"0", "100"}}, []string{"clark", "diana"}},
+		"null-string":      {StartsWith{"city", "got"}, []string{"bruce"}},
+		"null-string-nil":  {NE{"city", "Gotham"}, []string{"diana"}},
+		"null-isnull":      {IsNull{"city", "true"}, []string{"clark"}},
+		"null-generic":     {GTE{"Rating", "4.5"}, []string{"bruce", "diana"}},
+		"null-time":        {LT{"since", "1939-01-01T00:00:00Z"}, []string{"clark"}},
+		"null-time-isnull": {IsNull{"since", "false"}, []string{"bruce", "clark"}},
+		"duration":         {GT{"cooldown", "1h"}, []string{"diana"}},
+		"duration-between": {Between{"cooldown", "1m", "1h"}, []string{"bruce", "clark"}},
+		"named":            {EQ{"status", "active"}, []string{"bruce", "diana"}},
+		"named-regex":      {Regex{"status", "^ret"}, []string{"clark"}},
+		"valuer":           {GTE{"rank", "0"}, []string{"bruce", "diana"}},
+		"valuer-isnull":    {IsNull{"rank", "true"}, []string{"clark"}},
+		"valuer-pointer":   {EQ{"code", "BW"}, []string{"bruce"}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := Filters{tc.filter}.Compile(valueHero{})
+			assert.NoError(t, err)
+
+			var kept, compiled []string
+			for _, hero := range valueHeroes {
+				keep, err := tc.filter.Keep(hero)
+				assert.NoError(t, err)
+				if keep {
+					kept = append(kept, hero.Name)
+				}
+				keep, err = c.Keep(hero)
+				assert.NoError(t, err)
+				if keep {
+					compiled = append(compiled, hero.Name)
+				}
+			}
+			assert.Equal(t, tc.expected, kept)
+			assert.Equal(t, tc.expected, compiled)
+		})
+	}
+}
+
+func TestSortValues(t *testing.T) {
+	tests := map[string][]string{
+		"age":       {"clark", "bruce", "diana"},
+		"-power":    {"bruce", "clark", "diana"},
+		"city":      {"clark", "bruce", "diana"},
+		"-Rating":   {"diana", "bruce", "clark"},
+		"since":     {"diana", "clark", "bruce"},
+		"cooldown":  {"clark", "bruce", "diana"},
+		"-status":   {"clark", "bruce", "diana"},
+		"rank,name": {"clark", "diana", "bruce"},
+		"-code":     {"diana", "clark", "bruce"},
+	}
+	for sortBy, expected := range tests {
+		t.Run(sortBy, func(t *testing.T) {
+			heroes := append([]valueHero{}, valueHeroes...)
+			Option{SortBy: sortBy}.Sort(heroes)
+			var actual []string
+			for _, hero := range heroes {
+				actual = append(actual, hero.Name)
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestValidateValues(t *testing.T) {
+	schema := NewSchema(valueHero{})
+	assert.NoError(t, Option{
+		SortBy: "age,power,city,Rating,since,cooldown,status,rank,code",
+		Filters: Filters{
+			GT{"age", "10"},
+			LTE{"power", "18446744073709551615"},
+			Like{"city", "got"},
+			Between{"cooldown", "1m", "1h30m"},
+			EQ{"rank", "3"},
+			IsNull{"since", "true"},
+		},
+	}.Validate(schema))
+
+	err := Option{Filters: Filters{
+		GT{"power", "-1"},
+		LT{"cooldown", "60"},
+		Like{"rank", "3"},
+	}}.Validate(schema)
+	assert.Equal(t, ErrValidation{
+		{Param: "power", Op: "gt", Value: "-1", Reason: "invalid uint value"},
+		{Param: "cooldown", Op: "lt", Value: "60", Reason: "invalid duration value, supported format time.ParseDuration"},
+		{Param: "rank", Op: "like", Value: "3", Reason: "operator not supported on int field"},
+	}, err)
+}
+
+func TestNextCursorValues(t *testing.T) {
+	cursor, err := Option{SortBy: "power,cooldown,city"}.NextCursor(valueHeroes[0])
+	assert.NoError(t, err)
+	values, err := decodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"18446744073709551615", "1h0m0s", "Gotham"}, values)
+
+	_, err = Option{SortBy: "city"}.NextCursor(valueHeroes[1])
+	assert.EqualError(t, err, "filter : null sort field (city)")
+}